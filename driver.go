@@ -1,6 +1,10 @@
 package lightmigrate
 
-import "io"
+import (
+	"context"
+	"io"
+	"time"
+)
 
 // MigrationDriver is the interface every database driver must implement.
 type MigrationDriver interface {
@@ -11,7 +15,7 @@ type MigrationDriver interface {
 	// Lock should acquire a database lock so that only one migration process
 	// can run at a time. Migrate will call this function before Run is called.
 	// If the implementation can't provide this functionality, return nil.
-	// Return database.ErrLocked if database is already locked.
+	// Return ErrLocked if database is already locked.
 	Lock() error
 
 	// Unlock should release the lock. Migrate will call this function after
@@ -34,3 +38,54 @@ type MigrationDriver interface {
 	// Reset deletes everything related to LightMigrate in the database.
 	Reset() error
 }
+
+// MigrationDriverContext is an optional interface a database driver can implement
+// to support cancellation and deadlines. If a driver implements this interface, the
+// migrator will use the context aware functions instead of Lock and RunMigration.
+type MigrationDriverContext interface {
+	MigrationDriver
+
+	// LockContext is like Lock but should give up acquiring the lock once ctx is done.
+	LockContext(ctx context.Context) error
+
+	// RunMigrationContext is like RunMigration but should abort the migration once ctx is done.
+	RunMigrationContext(ctx context.Context, migration io.Reader) error
+}
+
+// AppliedMigration describes a single migration that has been applied to the database.
+type AppliedMigration struct {
+	// Version is the version of the migration.
+	Version uint64
+
+	// Identifier helps finding the migration in the source.
+	Identifier string
+
+	// Direction is either Up or Down.
+	Direction Direction
+
+	// Checksum is the hex encoded SHA-256 hash of the migration body.
+	Checksum string
+
+	// StartedAt is the time the migration was started.
+	StartedAt time.Time
+
+	// FinishedAt is the time the migration was finished.
+	FinishedAt time.Time
+
+	// AppliedBy is the host name of the machine that applied the migration.
+	AppliedBy string
+}
+
+// HistoryDriver is an optional interface a database driver can implement to keep track
+// of all applied migrations. If a driver implements this interface, the migrator
+// records every applied migration.
+type HistoryDriver interface {
+	MigrationDriver
+
+	// RecordApplied stores a migration that has been applied. The migrator calls this function
+	// after RunMigration succeeded and before the dirty state gets removed.
+	RecordApplied(migration AppliedMigration) error
+
+	// ListApplied returns all recorded migrations in the order they have been applied.
+	ListApplied() ([]AppliedMigration, error)
+}
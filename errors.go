@@ -16,6 +16,53 @@ func (e ErrDuplicateMigration) Error() string {
 	return "duplicate migration file: " + e.Name()
 }
 
+// ErrDuplicateSourceMigration is used to signal that multiple sources contain a migration with
+// the same version and direction.
+type ErrDuplicateSourceMigration struct {
+	// Version is the version of the duplicate migration.
+	Version uint64
+
+	// Direction is the direction of the duplicate migration.
+	Direction Direction
+
+	// Identifier is the identifier of the duplicate migration.
+	Identifier string
+}
+
+// Error implements error interface.
+func (e ErrDuplicateSourceMigration) Error() string {
+	return fmt.Sprintf("duplicate migration in sources: %d_%s.%s", e.Version, e.Identifier, e.Direction)
+}
+
+// ErrChecksumMismatch is used to signal that an already applied migration has been modified in the source.
+type ErrChecksumMismatch struct {
+	// Version is the version of the modified migration.
+	Version uint64
+
+	// Expected is the checksum stored in the migration history.
+	Expected string
+
+	// Actual is the checksum of the migration in the source.
+	Actual string
+}
+
+// Error implements error interface.
+func (e ErrChecksumMismatch) Error() string {
+	return fmt.Sprintf("checksum mismatch for migration %d: expected %s, got %s", e.Version, e.Expected, e.Actual)
+}
+
+// ErrSkippedMigrations is used to signal that migrations with a lower version than the current
+// database version have not been applied yet.
+type ErrSkippedMigrations struct {
+	// Versions contains all skipped migration versions.
+	Versions []uint64
+}
+
+// Error implements error interface.
+func (e ErrSkippedMigrations) Error() string {
+	return fmt.Sprintf("skipped migrations with versions lower than the current version: %v", e.Versions)
+}
+
 var (
 	// ErrDatabaseDirty is used to signal a dirty database.
 	ErrDatabaseDirty = fmt.Errorf("database contains unsuccessful migration")
@@ -23,6 +70,12 @@ var (
 	ErrNoChange = fmt.Errorf("no change")
 	// ErrVersionNotAllowed is used to signal that the version 0 is not a valid version.
 	ErrVersionNotAllowed = fmt.Errorf("version 0 is not allowed")
+	// ErrShortLimit is used to signal that fewer migrations are available than requested.
+	ErrShortLimit = fmt.Errorf("not enough migrations available")
+	// ErrLocked is used to signal that the database is already locked by another migration process.
+	ErrLocked = fmt.Errorf("database is locked")
+	// ErrNoSections is used to signal that a single file migration contains neither an up nor a down section.
+	ErrNoSections = fmt.Errorf("migration file contains no sections")
 )
 
 // DriverError should be used for errors involving queries ran against the database
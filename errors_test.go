@@ -71,3 +71,37 @@ func TestDriverError_Unwrap_SubErr(t *testing.T) {
 		t.Errorf("Unwrap() = %v, want %v", got, wantSubErr)
 	}
 }
+
+func TestErrChecksumMismatch_Error(t *testing.T) {
+	e := ErrChecksumMismatch{
+		Version:  3,
+		Expected: "abc",
+		Actual:   "def",
+	}
+	wantMsg := "checksum mismatch for migration 3: expected abc, got def"
+	if gotMsg := e.Error(); gotMsg != wantMsg {
+		t.Errorf("Error() = %v, want %v", gotMsg, wantMsg)
+	}
+}
+
+func TestErrSkippedMigrations_Error(t *testing.T) {
+	e := ErrSkippedMigrations{
+		Versions: []uint64{2, 4},
+	}
+	wantMsg := "skipped migrations with versions lower than the current version: [2 4]"
+	if gotMsg := e.Error(); gotMsg != wantMsg {
+		t.Errorf("Error() = %v, want %v", gotMsg, wantMsg)
+	}
+}
+
+func TestErrDuplicateSourceMigration_Error(t *testing.T) {
+	e := ErrDuplicateSourceMigration{
+		Version:    3,
+		Direction:  Up,
+		Identifier: "name",
+	}
+	wantMsg := "duplicate migration in sources: 3_name.up"
+	if gotMsg := e.Error(); gotMsg != wantMsg {
+		t.Errorf("Error() = %v, want %v", gotMsg, wantMsg)
+	}
+}
@@ -157,3 +157,168 @@ func TestMockedMigratorDirty(t *testing.T) {
 		t.Fatal("expected driver to be on migration version 2")
 	}
 }
+
+func TestMockedMigratorSteps(t *testing.T) {
+	fsys := os.DirFS(sampleFileRoot)
+
+	source, err := NewFsSource(fsys, sampleFilePath)
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+	defer source.Close()
+
+	driver, err := test.NewMockDriver()
+	if err != nil {
+		t.Fatalf("unable to setup driver: %v", err)
+	}
+	defer driver.Close()
+
+	migrator, err := NewMigrator(source, driver, WithVerboseLogging(true))
+	if err != nil {
+		t.Fatalf("unable to setup migrator: %v", err)
+	}
+
+	// Up two migrations
+	err = migrator.Steps(2)
+	if err != nil {
+		t.Fatalf("expected no migration error: %v", err)
+	}
+	if v, _, _ := driver.GetVersion(); v != 2 {
+		t.Fatal("expected driver to be on migration version 2")
+	}
+
+	// Revert the last migration
+	err = migrator.Steps(-1)
+	if err != nil {
+		t.Fatalf("expected no migration error: %v", err)
+	}
+	if v, _, _ := driver.GetVersion(); v != 1 {
+		t.Fatal("expected driver to be on migration version 1")
+	}
+
+	// Too many steps
+	err = migrator.Steps(3)
+	if err == nil {
+		t.Fatal("expected a migration error")
+	}
+	if v, _, _ := driver.GetVersion(); v != 1 {
+		t.Fatal("expected driver to be on migration version 1")
+	}
+}
+
+func TestMockedMigratorForce(t *testing.T) {
+	fsys := os.DirFS(sampleFileRoot)
+
+	source, err := NewFsSource(fsys, sampleFilePath)
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+	defer source.Close()
+
+	driver, err := test.NewMockDriver()
+	if err != nil {
+		t.Fatalf("unable to setup driver: %v", err)
+	}
+	driver.Version = 2
+	driver.Dirty = true
+	defer driver.Close()
+
+	migrator, err := NewMigrator(source, driver, WithVerboseLogging(true))
+	if err != nil {
+		t.Fatalf("unable to setup migrator: %v", err)
+	}
+
+	// Recover from the dirty state
+	err = migrator.Force(1)
+	if err != nil {
+		t.Fatalf("expected no force error: %v", err)
+	}
+	if v, dirty, _ := driver.GetVersion(); v != 1 || dirty {
+		t.Fatal("expected driver to be on clean migration version 1")
+	}
+
+	// Migrate again
+	err = migrator.Migrate(3)
+	if err != nil {
+		t.Fatalf("expected no migration error: %v", err)
+	}
+	if v, _, _ := driver.GetVersion(); v != 3 {
+		t.Fatal("expected driver to be on migration version 3")
+	}
+}
+
+func TestMockedMigratorSparse(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		versions []uint64
+	}{
+		{name: "sparse", path: "sparse-migrations", versions: []uint64{10, 20, 30}},
+		{name: "timestamp", path: "timestamp-migrations", versions: []uint64{20210101120000, 20220412214116, 20230615083000}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, err := NewFsSource(os.DirFS(sampleFileRoot), tt.path)
+			if err != nil {
+				t.Fatalf("unable to setup source: %v", err)
+			}
+			defer source.Close()
+
+			driver, err := test.NewMockDriver()
+			if err != nil {
+				t.Fatalf("unable to setup driver: %v", err)
+			}
+			defer driver.Close()
+
+			migrator, err := NewMigrator(source, driver, WithVerboseLogging(true))
+			if err != nil {
+				t.Fatalf("unable to setup migrator: %v", err)
+			}
+
+			// Up to the last version
+			err = migrator.Migrate(tt.versions[2])
+			if err != nil {
+				t.Fatalf("expected no migration error: %v", err)
+			}
+			if v, _, _ := driver.GetVersion(); v != tt.versions[2] {
+				t.Fatalf("expected driver to be on migration version %d, got %d", tt.versions[2], v)
+			}
+
+			// Revert the last migration
+			err = migrator.Steps(-1)
+			if err != nil {
+				t.Fatalf("expected no migration error: %v", err)
+			}
+			if v, _, _ := driver.GetVersion(); v != tt.versions[1] {
+				t.Fatalf("expected driver to be on migration version %d, got %d", tt.versions[1], v)
+			}
+
+			// Up again, the recorded version must be usable for further migrations
+			err = migrator.Migrate(tt.versions[2])
+			if err != nil {
+				t.Fatalf("expected no migration error: %v", err)
+			}
+			if v, _, _ := driver.GetVersion(); v != tt.versions[2] {
+				t.Fatalf("expected driver to be on migration version %d, got %d", tt.versions[2], v)
+			}
+
+			// Down to the first version
+			err = migrator.Migrate(tt.versions[0])
+			if err != nil {
+				t.Fatalf("expected no migration error: %v", err)
+			}
+			if v, _, _ := driver.GetVersion(); v != tt.versions[0] {
+				t.Fatalf("expected driver to be on migration version %d, got %d", tt.versions[0], v)
+			}
+
+			// Remove all migrations
+			err = migrator.Migrate(NoMigrationVersion)
+			if err != nil {
+				t.Fatalf("expected no migration error: %v", err)
+			}
+			if v, _, _ := driver.GetVersion(); v != NoMigrationVersion {
+				t.Fatalf("expected driver to be on migration version 0, got %d", v)
+			}
+		})
+	}
+}
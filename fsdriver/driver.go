@@ -0,0 +1,232 @@
+// Package fsdriver implements a lightmigrate.MigrationDriver that migrates a directory tree, for example
+// configuration or data directories. Migrations are JSON arrays of file operations, see Operation.
+package fsdriver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+
+	"github.com/h44z/lightmigrate"
+)
+
+const (
+	// StateFile is the name of the file in the root directory that stores the version and dirty state.
+	StateFile = ".lightmigrate"
+	// LockFile is the name of the file in the root directory that is used for locking.
+	LockFile = ".lightmigrate.lock"
+)
+
+// state is the content of the state file.
+type state struct {
+	Version uint64 `json:"version"`
+	Dirty   bool   `json:"dirty"`
+}
+
+// Driver is a lightmigrate.MigrationDriver for directory trees.
+type Driver struct {
+	root   string
+	codecs map[string]Codec
+
+	mux  sync.Mutex
+	lock *os.File
+
+	logger  lightmigrate.Logger
+	verbose bool
+}
+
+// DriverOption is a function that can be used within the driver constructor to
+// modify the driver object.
+type DriverOption func(d *Driver)
+
+// WithCodec registers a codec for patching files with the given extension, for example ".yaml".
+// A codec for ".json" files is registered by default.
+func WithCodec(extension string, codec Codec) DriverOption {
+	return func(d *Driver) {
+		d.codecs[extension] = codec
+	}
+}
+
+// WithLogger sets the logging instance used by the driver.
+func WithLogger(logger lightmigrate.Logger) DriverOption {
+	return func(d *Driver) {
+		d.logger = logger
+	}
+}
+
+// WithVerboseLogging sets the verbose flag of the driver.
+func WithVerboseLogging(verbose bool) DriverOption {
+	return func(d *Driver) {
+		d.verbose = verbose
+	}
+}
+
+// NewDriver returns a new MigrationDriver for the given root directory, the directory must exist.
+func NewDriver(root string, opts ...DriverOption) (*Driver, error) {
+	d := &Driver{
+		root: root,
+		codecs: map[string]Codec{
+			".json": jsonCodec,
+		},
+		logger: log.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, &fs.PathError{Op: "open", Path: root, Err: errors.New("not a directory")}
+	}
+
+	return d, nil
+}
+
+// resolve returns the file system path of a slash separated path relative to the root directory.
+// Paths outside the root directory and the state and lock files are rejected.
+func (d *Driver) resolve(name string) (string, error) {
+	if !fs.ValidPath(name) || name == "." {
+		return "", &fs.PathError{Op: "resolve", Path: name, Err: fs.ErrInvalid}
+	}
+	if base := path.Base(name); path.Dir(name) == "." && (base == StateFile || base == LockFile) {
+		return "", &fs.PathError{Op: "resolve", Path: name, Err: fs.ErrPermission}
+	}
+	return filepath.Join(d.root, filepath.FromSlash(name)), nil
+}
+
+// Close is part of lightmigrate.MigrationDriver interface implementation.
+// A held lock is released.
+func (d *Driver) Close() error {
+	return d.Unlock()
+}
+
+// Lock is part of lightmigrate.MigrationDriver interface implementation.
+// On unix systems, the lock file is locked using flock. On other systems, the lock file is created
+// exclusively and must be removed manually if the process dies while holding the lock.
+func (d *Driver) Lock() error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	if d.lock != nil {
+		return lightmigrate.ErrLocked
+	}
+
+	f, err := lockFile(filepath.Join(d.root, LockFile))
+	if err != nil {
+		return err
+	}
+	d.lock = f
+
+	if d.verbose {
+		d.logger.Printf("acquired lock for %s", d.root)
+	}
+
+	return nil
+}
+
+// Unlock is part of lightmigrate.MigrationDriver interface implementation.
+func (d *Driver) Unlock() error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	if d.lock == nil {
+		return nil
+	}
+	if err := unlockFile(d.lock); err != nil {
+		return fmt.Errorf("unable to release lock: %w", err)
+	}
+	d.lock = nil
+
+	if d.verbose {
+		d.logger.Printf("released lock for %s", d.root)
+	}
+
+	return nil
+}
+
+// GetVersion is part of lightmigrate.MigrationDriver interface implementation.
+func (d *Driver) GetVersion() (version uint64, dirty bool, err error) {
+	data, err := os.ReadFile(filepath.Join(d.root, StateFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return lightmigrate.NoMigrationVersion, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	var s state
+	if err = json.Unmarshal(data, &s); err != nil {
+		return 0, false, fmt.Errorf("invalid state file: %w", err)
+	}
+
+	return s.Version, s.Dirty, nil
+}
+
+// SetVersion is part of lightmigrate.MigrationDriver interface implementation.
+// The state file is replaced atomically.
+func (d *Driver) SetVersion(version uint64, dirty bool) error {
+	data, err := json.Marshal(state{Version: version, Dirty: dirty})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(d.root, StateFile+".*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(append(data, '\n'))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), filepath.Join(d.root, StateFile))
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("unable to write state file: %w", err)
+	}
+
+	return nil
+}
+
+// RunMigration is part of lightmigrate.MigrationDriver interface implementation.
+// Operations are applied in order, the changes of already applied operations are not reverted if an
+// operation fails. The returned lightmigrate.DriverError contains the number of the failed operation as line.
+func (d *Driver) RunMigration(migration io.Reader) error {
+	raw, operations, err := parseOperations(migration)
+	if err != nil {
+		return err
+	}
+
+	for i, o := range operations {
+		if d.verbose {
+			d.logger.Printf("executing operation %d: %s", i+1, o.Op)
+		}
+		if err = o.apply(d); err != nil {
+			return lightmigrate.DriverError{Line: uint(i + 1), Query: raw[i], OrigErr: err}
+		}
+	}
+
+	return nil
+}
+
+// Reset is part of lightmigrate.MigrationDriver interface implementation.
+// The state file is removed.
+func (d *Driver) Reset() error {
+	err := os.Remove(filepath.Join(d.root, StateFile))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package fsdriver
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/h44z/lightmigrate"
+)
+
+func TestNewDriver_Invalid(t *testing.T) {
+	if _, err := NewDriver(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Fatalf("expected error for missing directory")
+	}
+
+	file := filepath.Join(t.TempDir(), "file")
+	_ = os.WriteFile(file, nil, 0644)
+	if _, err := NewDriver(file); err == nil {
+		t.Fatalf("expected error for file")
+	}
+}
+
+func TestDriver_Version(t *testing.T) {
+	d := getTestDriver(t)
+
+	version, dirty, err := d.GetVersion()
+	if err != nil || version != lightmigrate.NoMigrationVersion || dirty {
+		t.Fatalf("unexpected initial version: %d, %t, %v", version, dirty, err)
+	}
+
+	if err = d.SetVersion(7, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	version, dirty, err = d.GetVersion()
+	if err != nil || version != 7 || !dirty {
+		t.Fatalf("unexpected version: %d, %t, %v", version, dirty, err)
+	}
+	if got := readTestFile(t, d, StateFile); got != "{\"version\":7,\"dirty\":true}\n" {
+		t.Fatalf("unexpected state file: %s", got)
+	}
+
+	if err = d.Reset(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = d.Reset(); err != nil {
+		t.Fatalf("unexpected error on second reset: %v", err)
+	}
+	version, dirty, err = d.GetVersion()
+	if err != nil || version != lightmigrate.NoMigrationVersion || dirty {
+		t.Fatalf("unexpected version after reset: %d, %t, %v", version, dirty, err)
+	}
+
+	entries, _ := os.ReadDir(d.root)
+	if len(entries) != 0 {
+		t.Fatalf("expected no temporary files, got: %v", entries)
+	}
+}
+
+func TestDriver_GetVersion_Invalid(t *testing.T) {
+	d := getTestDriver(t)
+	writeTestFile(t, d, StateFile, "invalid")
+
+	if _, _, err := d.GetVersion(); err == nil {
+		t.Fatalf("expected error for invalid state file")
+	}
+}
+
+func TestDriver_Lock(t *testing.T) {
+	d1 := getTestDriver(t)
+	d2, _ := NewDriver(d1.root)
+
+	if err := d1.Lock(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := d1.Lock(); !errors.Is(err, lightmigrate.ErrLocked) {
+		t.Fatalf("expected ErrLocked, got: %v", err)
+	}
+	if err := d2.Lock(); !errors.Is(err, lightmigrate.ErrLocked) {
+		t.Fatalf("expected ErrLocked for same directory, got: %v", err)
+	}
+
+	if err := d1.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := d2.Lock(); err != nil {
+		t.Fatalf("unexpected error after unlock: %v", err)
+	}
+	if err := d2.Unlock(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := d2.Unlock(); err != nil {
+		t.Fatalf("unexpected error on second unlock: %v", err)
+	}
+}
+
+func TestDriver_RunMigration_Error(t *testing.T) {
+	d := getTestDriver(t)
+
+	err := d.RunMigration(strings.NewReader(`[
+		{"op": "write", "path": "a.txt", "content": "a"},
+		{"op": "move", "from": "missing.txt", "to": "b.txt"}
+	]`))
+	var driverErr lightmigrate.DriverError
+	if !errors.As(err, &driverErr) || driverErr.Line != 2 || !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected driver error for operation 2, got: %v", err)
+	}
+}
+
+func TestDriver_Migrator(t *testing.T) {
+	d := getTestDriver(t)
+	writeTestFile(t, d, "settings.json", `{"theme": "dark"}`)
+
+	source, err := lightmigrate.NewMemorySource(map[string]string{
+		"1_layout.up.json":   `[{"op": "move", "from": "settings.json", "to": "config/settings.json"}]`,
+		"1_layout.down.json": `[{"op": "move", "from": "config/settings.json", "to": "settings.json"}]`,
+		"2_port.up.json":     `[{"op": "patch", "path": "config/settings.json", "set": {"server.port": 80}}]`,
+		"2_port.down.json":   `[{"op": "patch", "path": "config/settings.json", "unset": ["server"]}]`,
+	})
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+	migrator, err := lightmigrate.NewMigrator(source, d)
+	if err != nil {
+		t.Fatalf("unable to setup migrator: %v", err)
+	}
+
+	if err = migrator.Migrate(2); err != nil {
+		t.Fatalf("unexpected migration error: %v", err)
+	}
+	want := "{\n  \"server\": {\n    \"port\": 80\n  },\n  \"theme\": \"dark\"\n}\n"
+	if got := readTestFile(t, d, "config/settings.json"); got != want {
+		t.Fatalf("unexpected content: %s", got)
+	}
+
+	if err = migrator.Migrate(1); err != nil {
+		t.Fatalf("unexpected migration error: %v", err)
+	}
+	version, dirty, _ := d.GetVersion()
+	if version != 1 || dirty {
+		t.Fatalf("unexpected version: %d, %t", version, dirty)
+	}
+}
@@ -0,0 +1,41 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package fsdriver
+
+import (
+	"errors"
+	"os"
+	"syscall"
+
+	"github.com/h44z/lightmigrate"
+)
+
+// lockFile acquires an exclusive flock on the given file. The lock is released by the
+// operating system if the process dies.
+func lockFile(name string) (*os.File, error) {
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err != nil {
+		_ = f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, lightmigrate.ErrLocked
+		}
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// unlockFile releases the lock, the lock file is kept.
+func unlockFile(f *os.File) error {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
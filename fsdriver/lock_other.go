@@ -0,0 +1,39 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package fsdriver
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"strconv"
+
+	"github.com/h44z/lightmigrate"
+)
+
+// lockFile exclusively creates the given file. If the process dies, the lock file must be
+// removed manually.
+func lockFile(name string) (*os.File, error) {
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return nil, lightmigrate.ErrLocked
+		}
+		return nil, err
+	}
+
+	// store the process id to simplify debugging of stale lock files
+	_, _ = f.WriteString(strconv.Itoa(os.Getpid()))
+
+	return f, nil
+}
+
+// unlockFile releases the lock by removing the lock file.
+func unlockFile(f *os.File) error {
+	err := f.Close()
+	if removeErr := os.Remove(f.Name()); err == nil {
+		err = removeErr
+	}
+	return err
+}
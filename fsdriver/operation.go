@@ -0,0 +1,267 @@
+package fsdriver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Operations of the migration script.
+const (
+	// OpMove moves the file or directory From to To. The parent directories of To are created.
+	OpMove = "move"
+	// OpDelete removes the file or directory Path, including all children.
+	OpDelete = "delete"
+	// OpWrite writes Content to the file Path. The parent directories are created.
+	OpWrite = "write"
+	// OpPatch modifies the structured document Path, see Set and Unset.
+	OpPatch = "patch"
+)
+
+// Operation is a single file operation of a migration. A migration is a JSON array of operations, for example:
+//
+//	[
+//	  {"op": "move", "from": "config.json", "to": "config/app.json"},
+//	  {"op": "patch", "path": "config/app.json", "set": {"server.port": 8080}, "unset": ["legacy"]}
+//	]
+//
+// All paths are slash separated and relative to the root directory of the driver.
+type Operation struct {
+	// Op is the operation, one of OpMove, OpDelete, OpWrite and OpPatch.
+	Op string `json:"op"`
+
+	// Path is the file for OpDelete, OpWrite and OpPatch.
+	Path string `json:"path,omitempty"`
+
+	// From is the source for OpMove.
+	From string `json:"from,omitempty"`
+
+	// To is the target for OpMove, it must not exist.
+	To string `json:"to,omitempty"`
+
+	// Content is the file content for OpWrite.
+	Content string `json:"content,omitempty"`
+
+	// Mode is the octal file mode for OpWrite, for example "0600". Defaults to "0644".
+	Mode string `json:"mode,omitempty"`
+
+	// Set contains the values to set for OpPatch. Keys are dot separated paths within the document,
+	// missing objects are created. Keys must not overlap, e.g. "a" and "a.b".
+	Set map[string]interface{} `json:"set,omitempty"`
+
+	// Unset contains dot separated paths to remove from the document for OpPatch.
+	Unset []string `json:"unset,omitempty"`
+}
+
+// Codec reads and writes structured documents for OpPatch. Documents are decoded into
+// map[string]interface{} values.
+type Codec struct {
+	// Unmarshal decodes a document, like json.Unmarshal.
+	Unmarshal func(data []byte, v interface{}) error
+
+	// Marshal encodes a document, like json.Marshal.
+	Marshal func(v interface{}) ([]byte, error)
+}
+
+// jsonCodec is the default codec for .json files.
+var jsonCodec = Codec{
+	Unmarshal: json.Unmarshal,
+	Marshal: func(v interface{}) ([]byte, error) {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	},
+}
+
+// parseOperations reads all operations of a migration.
+func parseOperations(migration io.Reader) ([]json.RawMessage, []Operation, error) {
+	var raw []json.RawMessage
+	if err := json.NewDecoder(migration).Decode(&raw); err != nil {
+		return nil, nil, fmt.Errorf("invalid migration, expected JSON array of operations: %w", err)
+	}
+
+	operations := make([]Operation, len(raw))
+	for i := range raw {
+		decoder := json.NewDecoder(bytes.NewReader(raw[i]))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&operations[i]); err != nil {
+			return nil, nil, fmt.Errorf("invalid operation %d: %w", i+1, err)
+		}
+	}
+
+	return raw, operations, nil
+}
+
+// apply executes the operation in the root directory of the driver.
+func (o Operation) apply(d *Driver) error {
+	switch o.Op {
+	case OpMove:
+		return o.move(d)
+	case OpDelete:
+		return o.delete(d)
+	case OpWrite:
+		return o.write(d)
+	case OpPatch:
+		return o.patch(d)
+	default:
+		return fmt.Errorf("unknown operation %q", o.Op)
+	}
+}
+
+func (o Operation) move(d *Driver) error {
+	from, err := d.resolve(o.From)
+	if err != nil {
+		return err
+	}
+	to, err := d.resolve(o.To)
+	if err != nil {
+		return err
+	}
+
+	if _, err = os.Lstat(to); err == nil {
+		return &fs.PathError{Op: "move", Path: o.To, Err: fs.ErrExist}
+	}
+	if err = os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return err
+	}
+	return os.Rename(from, to)
+}
+
+func (o Operation) delete(d *Driver) error {
+	p, err := d.resolve(o.Path)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(p)
+}
+
+func (o Operation) write(d *Driver) error {
+	p, err := d.resolve(o.Path)
+	if err != nil {
+		return err
+	}
+
+	mode := fs.FileMode(0644)
+	if o.Mode != "" {
+		m, err := strconv.ParseUint(o.Mode, 8, 32)
+		if err != nil {
+			return fmt.Errorf("invalid mode %q: %w", o.Mode, err)
+		}
+		mode = fs.FileMode(m).Perm()
+	}
+
+	if err = os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	if err = os.WriteFile(p, []byte(o.Content), mode); err != nil {
+		return err
+	}
+	return os.Chmod(p, mode) // WriteFile does not change the mode of existing files
+}
+
+func (o Operation) patch(d *Driver) error {
+	p, err := d.resolve(o.Path)
+	if err != nil {
+		return err
+	}
+	codec, ok := d.codecs[strings.ToLower(path.Ext(o.Path))]
+	if !ok {
+		return fmt.Errorf("no codec for %s", o.Path)
+	}
+
+	info, err := os.Stat(p)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return err
+	}
+
+	var document map[string]interface{}
+	if err = codec.Unmarshal(data, &document); err != nil {
+		return fmt.Errorf("unable to decode %s: %w", o.Path, err)
+	}
+	if document == nil {
+		document = make(map[string]interface{})
+	}
+
+	keys, err := o.setKeys()
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err = setValue(document, strings.Split(key, "."), o.Set[key]); err != nil {
+			return fmt.Errorf("unable to set %s: %w", key, err)
+		}
+	}
+	for _, key := range o.Unset {
+		unsetValue(document, strings.Split(key, "."))
+	}
+
+	data, err = codec.Marshal(document)
+	if err != nil {
+		return fmt.Errorf("unable to encode %s: %w", o.Path, err)
+	}
+	return os.WriteFile(p, data, info.Mode().Perm())
+}
+
+// setKeys returns the sorted keys of Set. Overlapping keys (e.g. "a" and "a.b") are rejected, as the
+// result would depend on the order of the keys.
+func (o Operation) setKeys() ([]string, error) {
+	keys := make([]string, 0, len(o.Set))
+	for key := range o.Set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, other := range keys {
+			if strings.HasPrefix(other, key+".") {
+				return nil, fmt.Errorf("overlapping keys %s and %s", key, other)
+			}
+		}
+	}
+	return keys, nil
+}
+
+// setValue sets the value at the given path, missing objects are created.
+func setValue(document map[string]interface{}, keys []string, value interface{}) error {
+	for _, key := range keys[:len(keys)-1] {
+		child, exists := document[key]
+		if !exists {
+			child = make(map[string]interface{})
+			document[key] = child
+		}
+		object, ok := child.(map[string]interface{})
+		if !ok {
+			return errors.New(key + " is not an object")
+		}
+		document = object
+	}
+	document[keys[len(keys)-1]] = value
+	return nil
+}
+
+// unsetValue removes the value at the given path, missing values are ignored.
+func unsetValue(document map[string]interface{}, keys []string) {
+	for _, key := range keys[:len(keys)-1] {
+		object, ok := document[key].(map[string]interface{})
+		if !ok {
+			return
+		}
+		document = object
+	}
+	delete(document, keys[len(keys)-1])
+}
@@ -0,0 +1,230 @@
+package fsdriver
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func getTestDriver(t *testing.T, opts ...DriverOption) *Driver {
+	d, err := NewDriver(t.TempDir(), opts...)
+	if err != nil {
+		t.Fatalf("unable to setup driver: %v", err)
+	}
+	return d
+}
+
+func writeTestFile(t *testing.T, d *Driver, name, content string) {
+	t.Helper()
+	p := filepath.Join(d.root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readTestFile(t *testing.T, d *Driver, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(d.root, filepath.FromSlash(name)))
+	if err != nil {
+		t.Fatalf("unable to read %s: %v", name, err)
+	}
+	return string(data)
+}
+
+func applyOperations(d *Driver, migration string) error {
+	_, operations, err := parseOperations(strings.NewReader(migration))
+	if err != nil {
+		return err
+	}
+	for _, o := range operations {
+		if err = o.apply(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func Test_parseOperations_Invalid(t *testing.T) {
+	for _, migration := range []string{`{"op": "move"}`, `[{"op": "move", "unknown": 1}]`, `[`} {
+		if _, _, err := parseOperations(strings.NewReader(migration)); err == nil {
+			t.Errorf("expected error for %s", migration)
+		}
+	}
+}
+
+func TestOperation_Move(t *testing.T) {
+	d := getTestDriver(t)
+	writeTestFile(t, d, "config.json", "{}")
+
+	err := applyOperations(d, `[{"op": "move", "from": "config.json", "to": "config/app.json"}]`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := readTestFile(t, d, "config/app.json"); got != "{}" {
+		t.Fatalf("unexpected content: %s", got)
+	}
+	if _, err = os.Stat(filepath.Join(d.root, "config.json")); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected source to be moved, got: %v", err)
+	}
+}
+
+func TestOperation_Move_TargetExists(t *testing.T) {
+	d := getTestDriver(t)
+	writeTestFile(t, d, "a.txt", "a")
+	writeTestFile(t, d, "b.txt", "b")
+
+	err := applyOperations(d, `[{"op": "move", "from": "a.txt", "to": "b.txt"}]`)
+	if !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("expected ErrExist, got: %v", err)
+	}
+	if got := readTestFile(t, d, "b.txt"); got != "b" {
+		t.Fatalf("expected target to be unchanged, got: %s", got)
+	}
+}
+
+func TestOperation_Delete(t *testing.T) {
+	d := getTestDriver(t)
+	writeTestFile(t, d, "cache/a/b.txt", "b")
+
+	err := applyOperations(d, `[{"op": "delete", "path": "cache"}, {"op": "delete", "path": "missing"}]`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(d.root, "cache")); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected directory to be deleted, got: %v", err)
+	}
+}
+
+func TestOperation_Write(t *testing.T) {
+	d := getTestDriver(t)
+
+	err := applyOperations(d, `[{"op": "write", "path": "secrets/token", "content": "abc", "mode": "0600"}]`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := readTestFile(t, d, "secrets/token"); got != "abc" {
+		t.Fatalf("unexpected content: %s", got)
+	}
+	info, _ := os.Stat(filepath.Join(d.root, "secrets", "token"))
+	if info.Mode().Perm() != 0600 && info.Mode().Perm() != 0666 { // windows only supports read-only flags
+		t.Fatalf("unexpected mode: %v", info.Mode())
+	}
+
+	err = applyOperations(d, `[{"op": "write", "path": "a.txt", "content": "a", "mode": "rw"}]`)
+	if err == nil {
+		t.Fatalf("expected error for invalid mode")
+	}
+}
+
+func TestOperation_Patch(t *testing.T) {
+	d := getTestDriver(t)
+	writeTestFile(t, d, "app.json", `{"server": {"host": "localhost", "legacy": true}, "debug": true}`)
+
+	err := applyOperations(d, `[{
+		"op": "patch",
+		"path": "app.json",
+		"set": {"server.port": 8080, "log.level": "info"},
+		"unset": ["server.legacy", "debug", "missing.key"]
+	}]`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `{
+  "log": {
+    "level": "info"
+  },
+  "server": {
+    "host": "localhost",
+    "port": 8080
+  }
+}
+`
+	if got := readTestFile(t, d, "app.json"); got != want {
+		t.Fatalf("unexpected content: %s", got)
+	}
+}
+
+func TestOperation_Patch_Errors(t *testing.T) {
+	d := getTestDriver(t)
+	writeTestFile(t, d, "app.json", `{"server": "localhost"}`)
+	writeTestFile(t, d, "app.yaml", `server: localhost`)
+
+	tests := []string{
+		`[{"op": "patch", "path": "app.json", "set": {"server.port": 8080}}]`,
+		`[{"op": "patch", "path": "app.yaml", "set": {"port": 8080}}]`,
+		`[{"op": "patch", "path": "missing.json", "set": {"port": 8080}}]`,
+	}
+	for _, migration := range tests {
+		if err := applyOperations(d, migration); err == nil {
+			t.Errorf("expected error for %s", migration)
+		}
+	}
+}
+
+func TestOperation_Patch_Codec(t *testing.T) {
+	var encoded bool
+	codec := jsonCodec
+	codec.Marshal = func(v interface{}) ([]byte, error) {
+		encoded = true
+		return jsonCodec.Marshal(v)
+	}
+	d := getTestDriver(t, WithCodec(".yaml", codec))
+	writeTestFile(t, d, "app.yaml", `{}`)
+
+	if err := applyOperations(d, `[{"op": "patch", "path": "app.yaml", "set": {"port": 1}}]`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !encoded {
+		t.Fatalf("expected custom codec to be used")
+	}
+}
+
+func TestOperation_InvalidPaths(t *testing.T) {
+	d := getTestDriver(t)
+
+	tests := []string{
+		`[{"op": "unknown"}]`,
+		`[{"op": "delete", "path": "../outside"}]`,
+		`[{"op": "delete", "path": "/etc"}]`,
+		`[{"op": "delete", "path": "."}]`,
+		`[{"op": "delete", "path": ".lightmigrate"}]`,
+		`[{"op": "write", "path": ".lightmigrate.lock", "content": ""}]`,
+		`[{"op": "move", "from": "a", "to": "../b"}]`,
+	}
+	for _, migration := range tests {
+		if err := applyOperations(d, migration); err == nil {
+			t.Errorf("expected error for %s", migration)
+		}
+	}
+}
+
+func TestOperation_Patch_OverlappingKeys(t *testing.T) {
+	d := getTestDriver(t)
+	writeTestFile(t, d, "app.json", `{}`)
+
+	for i := 0; i < 20; i++ { // map iteration order is random
+		err := applyOperations(d, `[{"op": "patch", "path": "app.json", "set": {"a": 1, "a.b": 2, "ab": 3}}]`)
+		if err == nil || !strings.Contains(err.Error(), "overlapping keys a and a.b") {
+			t.Fatalf("expected overlapping keys error, got: %v", err)
+		}
+	}
+	if got := readTestFile(t, d, "app.json"); got != "{}" {
+		t.Fatalf("expected file to be unchanged, got: %s", got)
+	}
+
+	err := applyOperations(d, `[{"op": "patch", "path": "app.json", "set": {"a.c": 1, "a.b": 2, "ab": 3}}]`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "{\n  \"a\": {\n    \"b\": 2,\n    \"c\": 1\n  },\n  \"ab\": 3\n}\n"
+	if got := readTestFile(t, d, "app.json"); got != want {
+		t.Fatalf("unexpected content: %s", got)
+	}
+}
@@ -0,0 +1,178 @@
+package lightmigrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+// checksumReader computes the checksum of all data read through it.
+type checksumReader struct {
+	io.Reader
+	hash hash.Hash
+}
+
+func newChecksumReader(r io.Reader) *checksumReader {
+	h := sha256.New()
+	return &checksumReader{
+		Reader: io.TeeReader(r, h),
+		hash:   h,
+	}
+}
+
+// Checksum reads all remaining data and returns the hex encoded SHA-256 hash.
+func (c *checksumReader) Checksum() (string, error) {
+	if _, err := io.Copy(io.Discard, c.Reader); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(c.hash.Sum(nil)), nil
+}
+
+// appliedVersions reduces the migration history to all versions that are currently applied.
+func appliedVersions(history []AppliedMigration) map[uint64]AppliedMigration {
+	applied := make(map[uint64]AppliedMigration)
+	for _, h := range history {
+		switch h.Direction {
+		case Up:
+			applied[h.Version] = h
+		case Down:
+			delete(applied, h.Version)
+		}
+	}
+	return applied
+}
+
+// historyBaseline returns the lowest version in the migration history. Lower versions have been
+// applied before the history was kept.
+func historyBaseline(history []AppliedMigration) uint64 {
+	baseline := NoMigrationVersion
+	for i, h := range history {
+		if i == 0 || h.Version < baseline {
+			baseline = h.Version
+		}
+	}
+	return baseline
+}
+
+// getAppliedVersions returns all currently applied versions and the history baseline if the driver
+// keeps a migration history. If the history is empty, ok is false.
+func (m *migrator) getAppliedVersions() (applied map[uint64]AppliedMigration, baseline uint64, ok bool, err error) {
+	d, ok := m.driver.(HistoryDriver)
+	if !ok {
+		return nil, NoMigrationVersion, false, nil
+	}
+
+	history, err := d.ListApplied()
+	if err != nil || len(history) == 0 {
+		return nil, NoMigrationVersion, false, err
+	}
+
+	return appliedVersions(history), historyBaseline(history), true, nil
+}
+
+// verifyChecksums compares the checksums of all applied up migrations with the current source contents.
+// Migrations that are no longer available in the source are ignored.
+func (m *migrator) verifyChecksums() error {
+	applied, _, ok, err := m.getAppliedVersions()
+	if err != nil || !ok {
+		return err
+	}
+
+	versions := make([]uint64, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i] < versions[j]
+	})
+
+	for _, version := range versions {
+		h := applied[version]
+		if h.Checksum == "" {
+			continue // nothing to compare with
+		}
+
+		contents, _, err := m.source.ReadUp(version)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		checksum, err := newChecksumReader(contents).Checksum()
+		_ = contents.Close()
+		if err != nil {
+			return err
+		}
+
+		if checksum != h.Checksum {
+			return ErrChecksumMismatch{
+				Version:  version,
+				Expected: h.Checksum,
+				Actual:   checksum,
+			}
+		}
+	}
+
+	return nil
+}
+
+// recordForced stores a forced version as applied in the history, so that it is neither reported as
+// skipped nor applied again. The migration has not been run, so no checksum is recorded.
+func (m *migrator) recordForced(version uint64) error {
+	d, ok := m.driver.(HistoryDriver)
+	if !ok || version == NoMigrationVersion {
+		return nil
+	}
+
+	history, err := d.ListApplied()
+	if err != nil {
+		return err
+	}
+	if _, isApplied := appliedVersions(history)[version]; isApplied {
+		return nil
+	}
+
+	identifier := ""
+	if contents, id, err := m.source.ReadUp(version); err == nil {
+		_ = contents.Close()
+		identifier = id
+	}
+	hostname, _ := os.Hostname()
+	now := time.Now()
+
+	return d.RecordApplied(AppliedMigration{
+		Version:    version,
+		Identifier: identifier,
+		Direction:  Up,
+		StartedAt:  now,
+		FinishedAt: now,
+		AppliedBy:  hostname,
+	})
+}
+
+// recordApplied stores the migration in the history if the driver supports it.
+func (m *migrator) recordApplied(migration *migrationData, checksum string, startedAt time.Time) error {
+	d, ok := m.driver.(HistoryDriver)
+	if !ok {
+		return nil
+	}
+
+	hostname, _ := os.Hostname()
+
+	return d.RecordApplied(AppliedMigration{
+		Version:    migration.Version,
+		Identifier: migration.Identifier,
+		Direction:  migration.Direction,
+		Checksum:   checksum,
+		StartedAt:  startedAt,
+		FinishedAt: time.Now(),
+		AppliedBy:  hostname,
+	})
+}
@@ -0,0 +1,245 @@
+package lightmigrate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/h44z/lightmigrate/test"
+)
+
+// mockHistoryDriver is a mocked driver implementation that keeps a migration history.
+type mockHistoryDriver struct {
+	*test.MockDriver
+	History      []AppliedMigration
+	HistoryError error
+}
+
+func (m *mockHistoryDriver) RecordApplied(migration AppliedMigration) error {
+	if m.HistoryError != nil {
+		return m.HistoryError
+	}
+	m.History = append(m.History, migration)
+	return nil
+}
+
+func (m *mockHistoryDriver) ListApplied() ([]AppliedMigration, error) {
+	return m.History, m.HistoryError
+}
+
+func getTestHistoryMigrator(min, max uint64) (*migrator, *mockHistoryDriver) {
+	m := getTestMigrator()
+	s, _ := test.NewMockSource(min, max)
+	d, _ := test.NewMockDriver()
+	hd := &mockHistoryDriver{MockDriver: d}
+	m.source = s
+	m.driver = hd
+
+	return m, hd
+}
+
+func Test_checksumReader(t *testing.T) {
+	r := newChecksumReader(strings.NewReader("test"))
+
+	buf := make([]byte, 2)
+	_, _ = r.Read(buf) // partially read the contents
+
+	got, err := r.Checksum()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	if got != want {
+		t.Fatalf("expected checksum: %s, got: %s", want, got)
+	}
+}
+
+func Test_appliedVersions(t *testing.T) {
+	history := []AppliedMigration{
+		{Version: 1, Direction: Up},
+		{Version: 2, Direction: Up},
+		{Version: 3, Direction: Up},
+		{Version: 3, Direction: Down},
+		{Version: 2, Direction: Down},
+		{Version: 2, Direction: Up, Checksum: "new"},
+	}
+
+	got := appliedVersions(history)
+	want := map[uint64]AppliedMigration{
+		1: {Version: 1, Direction: Up},
+		2: {Version: 2, Direction: Up, Checksum: "new"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected applied versions: %v, got: %v", want, got)
+	}
+}
+
+func Test_migrator_Migrate_History(t *testing.T) {
+	m, d := getTestHistoryMigrator(1, 3)
+	m.source.(*test.MockSource).Identifier = "mock"
+	m.source.(*test.MockSource).Contents = []byte("test")
+
+	err := m.Migrate(2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = m.Migrate(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(d.History) != 3 {
+		t.Fatalf("expected 3 history entries, got: %d", len(d.History))
+	}
+	hostname, _ := os.Hostname()
+	wantVersions := []uint64{1, 2, 2}
+	wantDirections := []Direction{Up, Up, Down}
+	for i, h := range d.History {
+		if h.Version != wantVersions[i] || h.Direction != wantDirections[i] {
+			t.Fatalf("unexpected history entry %d: %v", i, h)
+		}
+		if h.Identifier != "mock" || h.AppliedBy != hostname {
+			t.Fatalf("unexpected history entry %d: %v", i, h)
+		}
+		if h.Checksum != "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08" {
+			t.Fatalf("unexpected checksum for entry %d: %s", i, h.Checksum)
+		}
+		if h.StartedAt.IsZero() || h.FinishedAt.Before(h.StartedAt) {
+			t.Fatalf("unexpected timestamps for entry %d: %v", i, h)
+		}
+	}
+}
+
+func Test_migrator_applyMigrations_HistoryError(t *testing.T) {
+	m, d := getTestHistoryMigrator(1, 3)
+	m.shutdown = make(chan bool, 1)
+	d.HistoryError = errors.New("historyerror")
+
+	migrations := make(chan *migrationData, 1)
+	migrations <- &migrationData{Version: 1, TargetVersion: 1, Contents: io.NopCloser(bytes.NewReader([]byte("test1")))}
+	close(migrations)
+
+	err := m.applyMigrations(context.Background(), migrations)
+	if err != d.HistoryError {
+		t.Fatalf("expected history error, got: %v", err)
+	}
+	if !d.Dirty {
+		t.Fatalf("expected database to be dirty")
+	}
+}
+
+func Test_migrator_Status_History(t *testing.T) {
+	m, d := getTestHistoryMigrator(1, 3)
+	d.Version = 3
+	d.History = []AppliedMigration{
+		{Version: 1, Direction: Up},
+		{Version: 3, Direction: Up},
+	}
+
+	got, err := m.Status()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pending := got.Pending()
+	if len(pending) != 1 || pending[0].Version != 2 {
+		t.Fatalf("expected version 2 to be pending, got: %v", pending)
+	}
+}
+
+func Test_migrator_Status_HistoryError(t *testing.T) {
+	m, d := getTestHistoryMigrator(1, 3)
+	d.HistoryError = errors.New("historyerror")
+
+	_, err := m.Status()
+	if err != d.HistoryError {
+		t.Fatalf("expected history error, got: %v", err)
+	}
+}
+
+func Test_migrator_Migrate_ChecksumMismatch(t *testing.T) {
+	m, d := getTestHistoryMigrator(1, 3)
+	m.source.(*test.MockSource).Contents = []byte("test")
+
+	err := m.Migrate(2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// modify the already applied migrations
+	m.source.(*test.MockSource).Contents = []byte("modified")
+
+	err = m.Migrate(3)
+	var mismatch ErrChecksumMismatch
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("expected ErrChecksumMismatch error, got: %v", err)
+	}
+	if mismatch.Version != 1 {
+		t.Fatalf("expected mismatch for version 1, got: %d", mismatch.Version)
+	}
+	if mismatch.Expected != d.History[0].Checksum || mismatch.Actual == mismatch.Expected {
+		t.Fatalf("unexpected checksums: %v", mismatch)
+	}
+	if d.Version != 2 {
+		t.Fatalf("expected database to be unchanged, got version: %d", d.Version)
+	}
+}
+
+func Test_migrator_verifyChecksums(t *testing.T) {
+	m, d := getTestHistoryMigrator(1, 3)
+	m.source.(*test.MockSource).Contents = []byte("test")
+	d.History = []AppliedMigration{
+		{Version: 1, Direction: Up, Checksum: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"},
+		{Version: 2, Direction: Up},                    // no checksum recorded
+		{Version: 5, Direction: Up, Checksum: "other"}, // no longer in source
+	}
+
+	err := m.verifyChecksums()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func Test_migrator_verifyChecksums_NoHistory(t *testing.T) {
+	m := getTestMigrator()
+
+	err := m.verifyChecksums()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func Test_migrator_Status_EmptyHistory(t *testing.T) {
+	m, d := getTestHistoryMigrator(1, 3)
+	d.Version = 2 // migrated without history support
+
+	got, err := m.Status()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pending := got.Pending()
+	if len(pending) != 1 || pending[0].Version != 3 {
+		t.Fatalf("expected version 3 to be pending, got: %v", pending)
+	}
+}
+
+func Test_migrator_Status_HistoryBaseline(t *testing.T) {
+	m, d := getTestHistoryMigrator(1, 4)
+	d.Version = 3
+	d.History = []AppliedMigration{
+		{Version: 3, Direction: Up}, // versions 1 and 2 have been applied before the history was kept
+	}
+
+	got, err := m.Status()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pending := got.Pending()
+	if len(pending) != 1 || pending[0].Version != 4 {
+		t.Fatalf("expected version 4 to be pending, got: %v", pending)
+	}
+}
@@ -0,0 +1,193 @@
+package kvdriver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Operations of the migration command language.
+const (
+	// OpPut sets the Value of Key in Bucket. The bucket is created if it does not exist.
+	OpPut = "put"
+	// OpDelete removes Key from Bucket.
+	OpDelete = "delete"
+	// OpRenamePrefix replaces the prefix From with To for all keys in Bucket.
+	OpRenamePrefix = "rename-prefix"
+	// OpCopyBucket copies all key/value pairs of Bucket to the bucket To.
+	OpCopyBucket = "copy-bucket"
+)
+
+// Command is a single operation of a migration. A migration is a JSON array of commands, for example:
+//
+//	[
+//	  {"op": "put", "bucket": "settings", "key": "theme", "value": "dark"},
+//	  {"op": "rename-prefix", "bucket": "users", "from": "user:", "to": "u:"}
+//	]
+type Command struct {
+	// Op is the operation, one of OpPut, OpDelete, OpRenamePrefix and OpCopyBucket.
+	Op string `json:"op"`
+
+	// Bucket is the bucket the operation is applied to.
+	Bucket string `json:"bucket"`
+
+	// Key is the key for OpPut and OpDelete.
+	Key string `json:"key,omitempty"`
+
+	// Value is the value for OpPut. JSON strings are stored without quotes, all other
+	// JSON values (e.g. objects) are stored as JSON.
+	Value json.RawMessage `json:"value,omitempty"`
+
+	// From is the old key prefix for OpRenamePrefix.
+	From string `json:"from,omitempty"`
+
+	// To is the new key prefix for OpRenamePrefix and the target bucket for OpCopyBucket.
+	To string `json:"to,omitempty"`
+}
+
+// parseCommands reads all commands of a migration.
+func parseCommands(migration io.Reader) ([]json.RawMessage, []Command, error) {
+	var raw []json.RawMessage
+	if err := json.NewDecoder(migration).Decode(&raw); err != nil {
+		return nil, nil, fmt.Errorf("invalid migration, expected JSON array of commands: %w", err)
+	}
+
+	commands := make([]Command, len(raw))
+	for i := range raw {
+		decoder := json.NewDecoder(bytes.NewReader(raw[i]))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&commands[i]); err != nil {
+			return nil, nil, fmt.Errorf("invalid command %d: %w", i+1, err)
+		}
+	}
+
+	return raw, commands, nil
+}
+
+// value returns the value to store for OpPut.
+func (c Command) value() ([]byte, error) {
+	if len(c.Value) == 0 {
+		return nil, fmt.Errorf("missing value")
+	}
+	if c.Value[0] != '"' {
+		return c.Value, nil
+	}
+	var s string
+	if err := json.Unmarshal(c.Value, &s); err != nil {
+		return nil, err
+	}
+	return []byte(s), nil
+}
+
+// apply executes the command within the given transaction.
+func (c Command) apply(tx Tx) error {
+	if c.Bucket == "" {
+		return fmt.Errorf("missing bucket")
+	}
+
+	switch c.Op {
+	case OpPut:
+		return c.put(tx)
+	case OpDelete:
+		return c.delete(tx)
+	case OpRenamePrefix:
+		return c.renamePrefix(tx)
+	case OpCopyBucket:
+		return c.copyBucket(tx)
+	default:
+		return fmt.Errorf("unknown operation %q", c.Op)
+	}
+}
+
+func (c Command) put(tx Tx) error {
+	if c.Key == "" {
+		return fmt.Errorf("missing key")
+	}
+	value, err := c.value()
+	if err != nil {
+		return err
+	}
+	b, err := tx.CreateBucketIfNotExists([]byte(c.Bucket))
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(c.Key), value)
+}
+
+func (c Command) delete(tx Tx) error {
+	if c.Key == "" {
+		return fmt.Errorf("missing key")
+	}
+	b := tx.Bucket([]byte(c.Bucket))
+	if b == nil {
+		return nil // nothing to delete
+	}
+	return b.Delete([]byte(c.Key))
+}
+
+func (c Command) renamePrefix(tx Tx) error {
+	if c.From == "" {
+		return fmt.Errorf("missing prefix")
+	}
+	b := tx.Bucket([]byte(c.Bucket))
+	if b == nil {
+		return nil // nothing to rename
+	}
+
+	// collect keys first, the bucket must not be modified while iterating
+	renamed := make(map[string][]byte)
+	err := b.ForEach(func(key, value []byte) error {
+		if value != nil && bytes.HasPrefix(key, []byte(c.From)) {
+			renamed[string(key)] = append([]byte(nil), value...)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for key := range renamed {
+		if err = b.Delete([]byte(key)); err != nil {
+			return err
+		}
+	}
+	for key, value := range renamed {
+		if err = b.Put([]byte(c.To+key[len(c.From):]), value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c Command) copyBucket(tx Tx) error {
+	if c.To == "" {
+		return fmt.Errorf("missing target bucket")
+	}
+	src := tx.Bucket([]byte(c.Bucket))
+	if src == nil {
+		return ErrBucketNotFound
+	}
+
+	pairs := make(map[string][]byte)
+	err := src.ForEach(func(key, value []byte) error {
+		if value != nil { // nested buckets are not copied
+			pairs[string(key)] = append([]byte(nil), value...)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	dst, err := tx.CreateBucketIfNotExists([]byte(c.To))
+	if err != nil {
+		return err
+	}
+	for key, value := range pairs {
+		if err = dst.Put([]byte(key), value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package kvdriver
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func getBucket(t *testing.T, s Store, name string) map[string]string {
+	t.Helper()
+
+	var pairs map[string]string
+	_ = s.View(func(tx Tx) error {
+		b := tx.Bucket([]byte(name))
+		if b == nil {
+			return nil
+		}
+		pairs = make(map[string]string)
+		return b.ForEach(func(key, value []byte) error {
+			pairs[string(key)] = string(value)
+			return nil
+		})
+	})
+	return pairs
+}
+
+func applyCommands(s Store, migration string) error {
+	_, commands, err := parseCommands(strings.NewReader(migration))
+	if err != nil {
+		return err
+	}
+	return s.Update(func(tx Tx) error {
+		for _, c := range commands {
+			if err := c.apply(tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func Test_parseCommands(t *testing.T) {
+	_, commands, err := parseCommands(strings.NewReader(`[{"op": "put", "bucket": "a", "key": "k", "value": "v"}]`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Command{{Op: OpPut, Bucket: "a", Key: "k", Value: []byte(`"v"`)}}
+	if !reflect.DeepEqual(commands, want) {
+		t.Fatalf("unexpected commands: %#v", commands)
+	}
+}
+
+func Test_parseCommands_Invalid(t *testing.T) {
+	for _, migration := range []string{`{"op": "put"}`, `[{"op": "put", "unknown": 1}]`, `[`} {
+		if _, _, err := parseCommands(strings.NewReader(migration)); err == nil {
+			t.Errorf("expected error for %s", migration)
+		}
+	}
+}
+
+func TestCommand_Put(t *testing.T) {
+	s := NewMemoryStore()
+
+	err := applyCommands(s, `[
+		{"op": "put", "bucket": "settings", "key": "theme", "value": "dark"},
+		{"op": "put", "bucket": "settings", "key": "window", "value": {"width": 800}}
+	]`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{"theme": "dark", "window": `{"width": 800}`}
+	if got := getBucket(t, s, "settings"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected bucket: %v", got)
+	}
+}
+
+func TestCommand_Delete(t *testing.T) {
+	s := NewMemoryStore()
+
+	err := applyCommands(s, `[
+		{"op": "put", "bucket": "settings", "key": "theme", "value": "dark"},
+		{"op": "put", "bucket": "settings", "key": "lang", "value": "en"},
+		{"op": "delete", "bucket": "settings", "key": "theme"},
+		{"op": "delete", "bucket": "missing", "key": "theme"}
+	]`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{"lang": "en"}
+	if got := getBucket(t, s, "settings"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected bucket: %v", got)
+	}
+}
+
+func TestCommand_RenamePrefix(t *testing.T) {
+	s := NewMemoryStore()
+
+	err := applyCommands(s, `[
+		{"op": "put", "bucket": "users", "key": "user:1", "value": "alice"},
+		{"op": "put", "bucket": "users", "key": "user:2", "value": "bob"},
+		{"op": "put", "bucket": "users", "key": "group:1", "value": "admins"},
+		{"op": "rename-prefix", "bucket": "users", "from": "user:", "to": "u:"}
+	]`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{"u:1": "alice", "u:2": "bob", "group:1": "admins"}
+	if got := getBucket(t, s, "users"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected bucket: %v", got)
+	}
+}
+
+func TestCommand_CopyBucket(t *testing.T) {
+	s := NewMemoryStore()
+
+	err := applyCommands(s, `[
+		{"op": "put", "bucket": "users", "key": "1", "value": "alice"},
+		{"op": "copy-bucket", "bucket": "users", "to": "users_v2"}
+	]`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{"1": "alice"}
+	if got := getBucket(t, s, "users_v2"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected bucket: %v", got)
+	}
+	if got := getBucket(t, s, "users"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected source bucket to be unchanged: %v", got)
+	}
+}
+
+func TestCommand_Invalid(t *testing.T) {
+	tests := []string{
+		`[{"op": "unknown", "bucket": "a"}]`,
+		`[{"op": "put", "key": "k", "value": "v"}]`,
+		`[{"op": "put", "bucket": "a", "value": "v"}]`,
+		`[{"op": "put", "bucket": "a", "key": "k"}]`,
+		`[{"op": "delete", "bucket": "a"}]`,
+		`[{"op": "rename-prefix", "bucket": "a", "to": "b"}]`,
+		`[{"op": "copy-bucket", "bucket": "a"}]`,
+		`[{"op": "copy-bucket", "bucket": "missing", "to": "b"}]`,
+	}
+	for _, migration := range tests {
+		if err := applyCommands(NewMemoryStore(), migration); err == nil {
+			t.Errorf("expected error for %s", migration)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+// Package kvdriver implements a lightmigrate.MigrationDriver for embedded key/value stores.
+// Migrations are JSON arrays of commands, see Command.
+package kvdriver
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"sync"
+
+	"github.com/h44z/lightmigrate"
+)
+
+// DefaultStateBucket is the default name of the reserved bucket that stores the version and dirty state.
+const DefaultStateBucket = "lightmigrate"
+
+var (
+	versionKey = []byte("version")
+	dirtyKey   = []byte("dirty")
+)
+
+// ErrReservedBucket is returned if a migration modifies the reserved state bucket.
+var ErrReservedBucket = errors.New("bucket is reserved")
+
+// ErrStoreNotComparable is returned by NewDriver if the store can not be used as lock key, see WithLockName.
+var ErrStoreNotComparable = errors.New("store is not comparable, use WithLockName")
+
+// locks contains the lock keys of all locked stores of this process.
+var locks sync.Map
+
+// Driver is a lightmigrate.MigrationDriver for embedded key/value stores.
+type Driver struct {
+	store       Store
+	stateBucket string
+	lockKey     interface{}
+
+	mux    sync.Mutex
+	locked bool
+
+	logger  lightmigrate.Logger
+	verbose bool
+}
+
+// DriverOption is a function that can be used within the driver constructor to
+// modify the driver object.
+type DriverOption func(d *Driver)
+
+// WithStateBucket sets the name of the reserved bucket that stores the version and dirty state.
+func WithStateBucket(name string) DriverOption {
+	return func(d *Driver) {
+		d.stateBucket = name
+	}
+}
+
+// WithLockName sets the name used to lock the store within this process. Drivers with the same lock name
+// exclude each other. By default, the store itself is used as lock key.
+func WithLockName(name string) DriverOption {
+	return func(d *Driver) {
+		d.lockKey = name
+	}
+}
+
+// WithLogger sets the logging instance used by the driver.
+func WithLogger(logger lightmigrate.Logger) DriverOption {
+	return func(d *Driver) {
+		d.logger = logger
+	}
+}
+
+// WithVerboseLogging sets the verbose flag of the driver.
+func WithVerboseLogging(verbose bool) DriverOption {
+	return func(d *Driver) {
+		d.verbose = verbose
+	}
+}
+
+// NewDriver returns a new MigrationDriver for the given store, the store is not closed by Driver.Close.
+// Without WithLockName, the store must be comparable (e.g. a pointer), otherwise ErrStoreNotComparable
+// is returned.
+func NewDriver(store Store, opts ...DriverOption) (*Driver, error) {
+	d := &Driver{
+		store:       store,
+		stateBucket: DefaultStateBucket,
+		logger:      log.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	if d.lockKey == nil {
+		if !isComparable(store) {
+			return nil, ErrStoreNotComparable
+		}
+		d.lockKey = store
+	}
+
+	return d, nil
+}
+
+// isComparable reports whether the value can be used as map key. Comparing values that contain
+// uncomparable types (e.g. maps or slices) panics at runtime.
+func isComparable(v interface{}) (ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	return v == v
+}
+
+// Store returns the underlying store, for example to be used in Go migrations.
+func (d *Driver) Store() Store {
+	return d.store
+}
+
+// Close is part of lightmigrate.MigrationDriver interface implementation.
+// A held lock is released, the store is not closed.
+func (d *Driver) Close() error {
+	return d.Unlock()
+}
+
+// Lock is part of lightmigrate.MigrationDriver interface implementation.
+// Embedded stores can only be opened by a single process, so the lock only prevents concurrent
+// migrations of the same store within this process.
+func (d *Driver) Lock() error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	if d.locked {
+		return lightmigrate.ErrLocked
+	}
+	if _, loaded := locks.LoadOrStore(d.lockKey, true); loaded {
+		return lightmigrate.ErrLocked
+	}
+	d.locked = true
+
+	return nil
+}
+
+// Unlock is part of lightmigrate.MigrationDriver interface implementation.
+func (d *Driver) Unlock() error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	if !d.locked {
+		return nil
+	}
+	locks.Delete(d.lockKey)
+	d.locked = false
+
+	return nil
+}
+
+// GetVersion is part of lightmigrate.MigrationDriver interface implementation.
+func (d *Driver) GetVersion() (version uint64, dirty bool, err error) {
+	err = d.store.View(func(tx Tx) error {
+		b := tx.Bucket([]byte(d.stateBucket))
+		if b == nil {
+			return nil // no migration applied yet
+		}
+
+		if v := b.Get(versionKey); v != nil {
+			version, err = strconv.ParseUint(string(v), 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid version %q: %w", v, err)
+			}
+		}
+		if v := b.Get(dirtyKey); v != nil {
+			dirty, err = strconv.ParseBool(string(v))
+			if err != nil {
+				return fmt.Errorf("invalid dirty state %q: %w", v, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, false, fmt.Errorf("unable to read version: %w", err)
+	}
+
+	return version, dirty, nil
+}
+
+// SetVersion is part of lightmigrate.MigrationDriver interface implementation.
+func (d *Driver) SetVersion(version uint64, dirty bool) error {
+	err := d.store.Update(func(tx Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(d.stateBucket))
+		if err != nil {
+			return err
+		}
+		if err = b.Put(versionKey, []byte(strconv.FormatUint(version, 10))); err != nil {
+			return err
+		}
+		return b.Put(dirtyKey, []byte(strconv.FormatBool(dirty)))
+	})
+	if err != nil {
+		return fmt.Errorf("unable to store version: %w", err)
+	}
+
+	return nil
+}
+
+// RunMigration is part of lightmigrate.MigrationDriver interface implementation.
+// All commands of the migration are applied within a single transaction. If a command fails,
+// the returned lightmigrate.DriverError contains the number of the command as line.
+func (d *Driver) RunMigration(migration io.Reader) error {
+	raw, commands, err := parseCommands(migration)
+	if err != nil {
+		return err
+	}
+
+	return d.store.Update(func(tx Tx) error {
+		for i, c := range commands {
+			if d.verbose {
+				d.logger.Printf("executing command %d: %s %s", i+1, c.Op, c.Bucket)
+			}
+			if c.Bucket == d.stateBucket || (c.Op == OpCopyBucket && c.To == d.stateBucket) {
+				return lightmigrate.DriverError{Line: uint(i + 1), Query: raw[i], OrigErr: ErrReservedBucket}
+			}
+			if err := c.apply(tx); err != nil {
+				return lightmigrate.DriverError{Line: uint(i + 1), Query: raw[i], OrigErr: err}
+			}
+		}
+		return nil
+	})
+}
+
+// Reset is part of lightmigrate.MigrationDriver interface implementation.
+// The reserved state bucket is deleted.
+func (d *Driver) Reset() error {
+	err := d.store.Update(func(tx Tx) error {
+		if tx.Bucket([]byte(d.stateBucket)) == nil {
+			return nil
+		}
+		return tx.DeleteBucket([]byte(d.stateBucket))
+	})
+	if err != nil {
+		return fmt.Errorf("unable to reset state: %w", err)
+	}
+
+	return nil
+}
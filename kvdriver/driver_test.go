@@ -0,0 +1,171 @@
+package kvdriver
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/h44z/lightmigrate"
+)
+
+func TestDriver_Version(t *testing.T) {
+	d, _ := NewDriver(NewMemoryStore())
+
+	version, dirty, err := d.GetVersion()
+	if err != nil || version != lightmigrate.NoMigrationVersion || dirty {
+		t.Fatalf("unexpected initial version: %d, %t, %v", version, dirty, err)
+	}
+
+	if err = d.SetVersion(5, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	version, dirty, err = d.GetVersion()
+	if err != nil || version != 5 || !dirty {
+		t.Fatalf("unexpected version: %d, %t, %v", version, dirty, err)
+	}
+
+	if err = d.Reset(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	version, dirty, err = d.GetVersion()
+	if err != nil || version != lightmigrate.NoMigrationVersion || dirty {
+		t.Fatalf("unexpected version after reset: %d, %t, %v", version, dirty, err)
+	}
+}
+
+func TestDriver_GetVersion_Invalid(t *testing.T) {
+	s := NewMemoryStore()
+	d, _ := NewDriver(s, WithStateBucket("state"))
+	_ = s.Update(func(tx Tx) error {
+		b, _ := tx.CreateBucketIfNotExists([]byte("state"))
+		return b.Put(versionKey, []byte("abc"))
+	})
+
+	if _, _, err := d.GetVersion(); err == nil {
+		t.Fatalf("expected error for invalid version")
+	}
+}
+
+func TestDriver_Lock(t *testing.T) {
+	s := NewMemoryStore()
+	d1, _ := NewDriver(s)
+	d2, _ := NewDriver(s)
+	other, _ := NewDriver(NewMemoryStore())
+
+	if err := d1.Lock(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := d1.Lock(); !errors.Is(err, lightmigrate.ErrLocked) {
+		t.Fatalf("expected ErrLocked, got: %v", err)
+	}
+	if err := d2.Lock(); !errors.Is(err, lightmigrate.ErrLocked) {
+		t.Fatalf("expected ErrLocked for same store, got: %v", err)
+	}
+	if err := other.Lock(); err != nil {
+		t.Fatalf("unexpected error for other store: %v", err)
+	}
+	_ = other.Close()
+
+	if err := d2.Unlock(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := d1.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := d2.Lock(); err != nil {
+		t.Fatalf("unexpected error after unlock: %v", err)
+	}
+	_ = d2.Unlock()
+}
+
+// mapStore is a store that can not be used as map key.
+type mapStore map[string]string
+
+func (s mapStore) View(fn func(tx Tx) error) error   { return nil }
+func (s mapStore) Update(fn func(tx Tx) error) error { return nil }
+
+func TestDriver_Lock_NotComparable(t *testing.T) {
+	if _, err := NewDriver(mapStore{}); !errors.Is(err, ErrStoreNotComparable) {
+		t.Fatalf("expected ErrStoreNotComparable, got: %v", err)
+	}
+	if _, err := NewDriver(struct{ Store }{mapStore{}}); !errors.Is(err, ErrStoreNotComparable) {
+		t.Fatalf("expected ErrStoreNotComparable for wrapped store, got: %v", err)
+	}
+
+	d1, err := NewDriver(mapStore{}, WithLockName("app"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	d2, _ := NewDriver(mapStore{}, WithLockName("app"))
+	if err = d1.Lock(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = d2.Lock(); !errors.Is(err, lightmigrate.ErrLocked) {
+		t.Fatalf("expected ErrLocked for same lock name, got: %v", err)
+	}
+	_ = d1.Unlock()
+}
+
+func TestDriver_RunMigration_Error(t *testing.T) {
+	s := NewMemoryStore()
+	d, _ := NewDriver(s)
+
+	err := d.RunMigration(strings.NewReader(`[
+		{"op": "put", "bucket": "users", "key": "1", "value": "alice"},
+		{"op": "copy-bucket", "bucket": "missing", "to": "other"}
+	]`))
+	var driverErr lightmigrate.DriverError
+	if !errors.As(err, &driverErr) || driverErr.Line != 2 || !errors.Is(err, ErrBucketNotFound) {
+		t.Fatalf("expected driver error for command 2, got: %v", err)
+	}
+	if got := getBucket(t, s, "users"); got != nil {
+		t.Fatalf("expected migration to be rolled back, got: %v", got)
+	}
+}
+
+func TestDriver_RunMigration_ReservedBucket(t *testing.T) {
+	d, _ := NewDriver(NewMemoryStore())
+
+	for _, migration := range []string{
+		`[{"op": "put", "bucket": "lightmigrate", "key": "version", "value": "9"}]`,
+		`[{"op": "copy-bucket", "bucket": "users", "to": "lightmigrate"}]`,
+	} {
+		if err := d.RunMigration(strings.NewReader(migration)); !errors.Is(err, ErrReservedBucket) {
+			t.Errorf("expected ErrReservedBucket for %s, got: %v", migration, err)
+		}
+	}
+}
+
+func TestDriver_Migrator(t *testing.T) {
+	s := NewMemoryStore()
+	d, _ := NewDriver(s)
+
+	source, err := lightmigrate.NewMemorySource(map[string]string{
+		"1_settings.up.json":   `[{"op": "put", "bucket": "settings", "key": "theme", "value": "dark"}]`,
+		"1_settings.down.json": `[{"op": "delete", "bucket": "settings", "key": "theme"}]`,
+		"2_rename.up.json":     `[{"op": "rename-prefix", "bucket": "settings", "from": "the", "to": "ui."}]`,
+		"2_rename.down.json":   `[{"op": "rename-prefix", "bucket": "settings", "from": "ui.", "to": "the"}]`,
+	})
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+	migrator, err := lightmigrate.NewMigrator(source, d)
+	if err != nil {
+		t.Fatalf("unable to setup migrator: %v", err)
+	}
+
+	if err = migrator.Migrate(2); err != nil {
+		t.Fatalf("unexpected migration error: %v", err)
+	}
+	if got := getBucket(t, s, "settings"); !reflect.DeepEqual(got, map[string]string{"ui.me": "dark"}) {
+		t.Fatalf("unexpected bucket: %v", got)
+	}
+
+	if err = migrator.Migrate(1); err != nil {
+		t.Fatalf("unexpected migration error: %v", err)
+	}
+	if got := getBucket(t, s, "settings"); !reflect.DeepEqual(got, map[string]string{"theme": "dark"}) {
+		t.Fatalf("unexpected bucket: %v", got)
+	}
+}
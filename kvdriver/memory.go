@@ -0,0 +1,132 @@
+package kvdriver
+
+import (
+	"sort"
+	"sync"
+)
+
+// MemoryStore is an in-memory Store. Read-write transactions operate on a copy of the data,
+// which replaces the data once the transaction succeeded.
+type MemoryStore struct {
+	mux     sync.RWMutex
+	buckets map[string]map[string][]byte
+}
+
+// NewMemoryStore returns a new, empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]map[string][]byte),
+	}
+}
+
+// Update is part of Store interface implementation.
+func (s *MemoryStore) Update(fn func(tx Tx) error) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	tx := &memoryTx{
+		buckets:  make(map[string]map[string][]byte, len(s.buckets)),
+		writable: true,
+	}
+	for name, bucket := range s.buckets {
+		tx.buckets[name] = copyBucket(bucket)
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	s.buckets = tx.buckets
+
+	return nil
+}
+
+// View is part of Store interface implementation.
+func (s *MemoryStore) View(fn func(tx Tx) error) error {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	return fn(&memoryTx{buckets: s.buckets})
+}
+
+// copyBucket returns a deep copy of the bucket.
+func copyBucket(bucket map[string][]byte) map[string][]byte {
+	c := make(map[string][]byte, len(bucket))
+	for k, v := range bucket {
+		c[k] = append([]byte(nil), v...)
+	}
+	return c
+}
+
+type memoryTx struct {
+	buckets  map[string]map[string][]byte
+	writable bool
+}
+
+func (t *memoryTx) Bucket(name []byte) Bucket {
+	data, ok := t.buckets[string(name)]
+	if !ok {
+		return nil
+	}
+	return &memoryBucket{data: data, writable: t.writable}
+}
+
+func (t *memoryTx) CreateBucketIfNotExists(name []byte) (Bucket, error) {
+	if !t.writable {
+		return nil, ErrTxNotWritable
+	}
+	if _, ok := t.buckets[string(name)]; !ok {
+		t.buckets[string(name)] = make(map[string][]byte)
+	}
+	return t.Bucket(name), nil
+}
+
+func (t *memoryTx) DeleteBucket(name []byte) error {
+	if !t.writable {
+		return ErrTxNotWritable
+	}
+	if _, ok := t.buckets[string(name)]; !ok {
+		return ErrBucketNotFound
+	}
+	delete(t.buckets, string(name))
+	return nil
+}
+
+type memoryBucket struct {
+	data     map[string][]byte
+	writable bool
+}
+
+func (b *memoryBucket) Get(key []byte) []byte {
+	return b.data[string(key)]
+}
+
+func (b *memoryBucket) Put(key []byte, value []byte) error {
+	if !b.writable {
+		return ErrTxNotWritable
+	}
+	b.data[string(key)] = append([]byte(nil), value...)
+	return nil
+}
+
+func (b *memoryBucket) Delete(key []byte) error {
+	if !b.writable {
+		return ErrTxNotWritable
+	}
+	delete(b.data, string(key))
+	return nil
+}
+
+func (b *memoryBucket) ForEach(fn func(key, value []byte) error) error {
+	keys := make([]string, 0, len(b.data))
+	for k := range b.data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if err := fn([]byte(k), b.data[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
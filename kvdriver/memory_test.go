@@ -0,0 +1,100 @@
+package kvdriver
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMemoryStore_Update(t *testing.T) {
+	s := NewMemoryStore()
+
+	err := s.Update(func(tx Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte("users"))
+		if err != nil {
+			return err
+		}
+		_ = b.Put([]byte("b"), []byte("2"))
+		return b.Put([]byte("a"), []byte("1"))
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var keys []string
+	err = s.View(func(tx Tx) error {
+		return tx.Bucket([]byte("users")).ForEach(func(key, value []byte) error {
+			keys = append(keys, string(key)+"="+string(value))
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(keys, []string{"a=1", "b=2"}) {
+		t.Fatalf("unexpected keys: %v", keys)
+	}
+}
+
+func TestMemoryStore_Update_Rollback(t *testing.T) {
+	s := NewMemoryStore()
+	_ = s.Update(func(tx Tx) error {
+		b, _ := tx.CreateBucketIfNotExists([]byte("users"))
+		return b.Put([]byte("a"), []byte("1"))
+	})
+
+	wantErr := errors.New("failed")
+	err := s.Update(func(tx Tx) error {
+		_ = tx.Bucket([]byte("users")).Put([]byte("a"), []byte("2"))
+		_, _ = tx.CreateBucketIfNotExists([]byte("other"))
+		return wantErr
+	})
+	if err != wantErr {
+		t.Fatalf("expected error %v, got: %v", wantErr, err)
+	}
+
+	_ = s.View(func(tx Tx) error {
+		if v := tx.Bucket([]byte("users")).Get([]byte("a")); string(v) != "1" {
+			t.Errorf("expected rolled back value, got: %s", v)
+		}
+		if tx.Bucket([]byte("other")) != nil {
+			t.Errorf("expected bucket creation to be rolled back")
+		}
+		return nil
+	})
+}
+
+func TestMemoryStore_View_NotWritable(t *testing.T) {
+	s := NewMemoryStore()
+	_ = s.Update(func(tx Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte("users"))
+		return err
+	})
+
+	_ = s.View(func(tx Tx) error {
+		if _, err := tx.CreateBucketIfNotExists([]byte("other")); err != ErrTxNotWritable {
+			t.Errorf("expected ErrTxNotWritable, got: %v", err)
+		}
+		if err := tx.DeleteBucket([]byte("users")); err != ErrTxNotWritable {
+			t.Errorf("expected ErrTxNotWritable, got: %v", err)
+		}
+		if err := tx.Bucket([]byte("users")).Put([]byte("a"), nil); err != ErrTxNotWritable {
+			t.Errorf("expected ErrTxNotWritable, got: %v", err)
+		}
+		if err := tx.Bucket([]byte("users")).Delete([]byte("a")); err != ErrTxNotWritable {
+			t.Errorf("expected ErrTxNotWritable, got: %v", err)
+		}
+		return nil
+	})
+}
+
+func TestMemoryStore_DeleteBucket(t *testing.T) {
+	s := NewMemoryStore()
+
+	err := s.Update(func(tx Tx) error {
+		return tx.DeleteBucket([]byte("missing"))
+	})
+	if err != ErrBucketNotFound {
+		t.Fatalf("expected ErrBucketNotFound, got: %v", err)
+	}
+}
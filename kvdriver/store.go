@@ -0,0 +1,48 @@
+package kvdriver
+
+import "errors"
+
+var (
+	// ErrTxNotWritable is returned if a bucket is modified within a read-only transaction.
+	ErrTxNotWritable = errors.New("transaction not writable")
+	// ErrBucketNotFound is returned if a bucket does not exist.
+	ErrBucketNotFound = errors.New("bucket not found")
+)
+
+// Store is an embedded key/value store with buckets, like bbolt. Stores like bbolt or badger can be
+// used with a small adapter.
+type Store interface {
+	// Update executes fn within a read-write transaction. If fn returns an error, the transaction is rolled back.
+	Update(fn func(tx Tx) error) error
+
+	// View executes fn within a read-only transaction.
+	View(fn func(tx Tx) error) error
+}
+
+// Tx is a transaction of a Store.
+type Tx interface {
+	// Bucket returns the bucket with the given name, nil if the bucket does not exist.
+	Bucket(name []byte) Bucket
+
+	// CreateBucketIfNotExists returns the bucket with the given name, the bucket is created if it does not exist.
+	CreateBucketIfNotExists(name []byte) (Bucket, error)
+
+	// DeleteBucket deletes the bucket with the given name. Returns ErrBucketNotFound if the bucket does not exist.
+	DeleteBucket(name []byte) error
+}
+
+// Bucket is a collection of key/value pairs.
+type Bucket interface {
+	// Get returns the value of the key, nil if the key does not exist.
+	Get(key []byte) []byte
+
+	// Put sets the value of the key.
+	Put(key []byte, value []byte) error
+
+	// Delete removes the key, deleting a non-existing key does not fail.
+	Delete(key []byte) error
+
+	// ForEach calls fn for all key/value pairs of the bucket in key order.
+	// The bucket must not be modified within fn.
+	ForEach(fn func(key, value []byte) error) error
+}
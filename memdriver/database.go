@@ -0,0 +1,135 @@
+package memdriver
+
+import (
+	"sync"
+
+	"github.com/h44z/lightmigrate"
+)
+
+// Event is a recorded driver call. Only state changing calls are recorded: Lock, Unlock,
+// SetVersion, RunMigration, RecordApplied and Reset.
+type Event struct {
+	// Driver is the name of the driver, see WithName.
+	Driver string
+
+	// Method is the called method.
+	Method Method
+
+	// Version is the version for SetVersion and RecordApplied.
+	Version uint64
+
+	// Dirty is the dirty state for SetVersion.
+	Dirty bool
+
+	// Migration is the migration body for RunMigration.
+	Migration []byte
+
+	// Err is the error returned by the call.
+	Err error
+}
+
+// Database is the in-memory state of one or more drivers.
+type Database struct {
+	mux sync.Mutex
+
+	version uint64
+	dirty   bool
+	history []lightmigrate.AppliedMigration
+	events  []Event
+
+	owner    *Driver       // the driver holding the lock
+	released chan struct{} // closed once the lock is released
+}
+
+// NewDatabase instantiates a new, empty database.
+func NewDatabase() *Database {
+	return &Database{}
+}
+
+// Version returns the current version and dirty state.
+func (db *Database) Version() (version uint64, dirty bool) {
+	db.mux.Lock()
+	defer db.mux.Unlock()
+
+	return db.version, db.dirty
+}
+
+// History returns all recorded migrations in the order they have been applied.
+func (db *Database) History() []lightmigrate.AppliedMigration {
+	db.mux.Lock()
+	defer db.mux.Unlock()
+
+	return append([]lightmigrate.AppliedMigration(nil), db.history...)
+}
+
+// Events returns all recorded events in order.
+func (db *Database) Events() []Event {
+	db.mux.Lock()
+	defer db.mux.Unlock()
+
+	return append([]Event(nil), db.events...)
+}
+
+// Migrations returns the bodies of all successful RunMigration calls in order.
+func (db *Database) Migrations() []string {
+	db.mux.Lock()
+	defer db.mux.Unlock()
+
+	var migrations []string
+	for _, e := range db.events {
+		if e.Method == MethodRunMigration && e.Err == nil {
+			migrations = append(migrations, string(e.Migration))
+		}
+	}
+	return migrations
+}
+
+// Locked reports whether a driver holds the lock.
+func (db *Database) Locked() bool {
+	db.mux.Lock()
+	defer db.mux.Unlock()
+
+	return db.owner != nil
+}
+
+// record appends an event.
+func (db *Database) record(e Event) {
+	db.mux.Lock()
+	defer db.mux.Unlock()
+
+	db.events = append(db.events, e)
+}
+
+// tryLock acquires the lock for the given driver. If another driver holds the lock,
+// lightmigrate.ErrLocked and a channel that is closed once the lock is released are returned.
+func (db *Database) tryLock(d *Driver) (<-chan struct{}, error) {
+	db.mux.Lock()
+	defer db.mux.Unlock()
+
+	switch db.owner {
+	case nil:
+		db.owner = d
+		db.released = make(chan struct{})
+		db.events = append(db.events, Event{Driver: d.name, Method: MethodLock})
+		return nil, nil
+	case d:
+		db.events = append(db.events, Event{Driver: d.name, Method: MethodLock, Err: lightmigrate.ErrLocked})
+		return nil, lightmigrate.ErrLocked
+	default:
+		db.events = append(db.events, Event{Driver: d.name, Method: MethodLock, Err: lightmigrate.ErrLocked})
+		return db.released, lightmigrate.ErrLocked
+	}
+}
+
+// unlock releases the lock if it is held by the given driver.
+func (db *Database) unlock(d *Driver) {
+	db.mux.Lock()
+	defer db.mux.Unlock()
+
+	if db.owner != d {
+		return
+	}
+	db.owner = nil
+	close(db.released)
+	db.events = append(db.events, Event{Driver: d.name, Method: MethodUnlock})
+}
@@ -0,0 +1,277 @@
+// Package memdriver implements an in-memory lightmigrate.MigrationDriver. It records all state changing
+// calls in order and supports failure injection, which makes it useful to test migrations and drivers.
+package memdriver
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/h44z/lightmigrate"
+)
+
+// Method is the name of a driver method.
+type Method string
+
+// Driver methods, used for events and failure injection.
+const (
+	// AnyMethod matches calls of all methods for failure injection.
+	AnyMethod           Method = ""
+	MethodClose         Method = "Close"
+	MethodLock          Method = "Lock"
+	MethodUnlock        Method = "Unlock"
+	MethodGetVersion    Method = "GetVersion"
+	MethodSetVersion    Method = "SetVersion"
+	MethodRunMigration  Method = "RunMigration"
+	MethodReset         Method = "Reset"
+	MethodRecordApplied Method = "RecordApplied"
+	MethodListApplied   Method = "ListApplied"
+)
+
+// failure is an injected error for the n-th call of a method.
+type failure struct {
+	method Method
+	n      int
+	err    error
+}
+
+// Driver is an in-memory lightmigrate.MigrationDriver. It implements the optional
+// lightmigrate.MigrationDriverContext and lightmigrate.HistoryDriver interfaces.
+type Driver struct {
+	db   *Database
+	name string
+
+	mux      sync.Mutex
+	calls    map[Method]int
+	failures []failure
+}
+
+// DriverOption is a function that can be used within the driver constructor to
+// modify the driver object.
+type DriverOption func(d *Driver)
+
+// WithDatabase sets the database of the driver. Drivers sharing a database see the same state
+// and contend for the same lock. By default, each driver uses its own database.
+func WithDatabase(db *Database) DriverOption {
+	return func(d *Driver) {
+		d.db = db
+	}
+}
+
+// WithName sets the name of the driver, which is recorded in all events.
+func WithName(name string) DriverOption {
+	return func(d *Driver) {
+		d.name = name
+	}
+}
+
+// WithFailure makes the n-th call (starting at 1) of the given method fail with err.
+// Use AnyMethod to count the calls of all methods.
+func WithFailure(method Method, n int, err error) DriverOption {
+	return func(d *Driver) {
+		d.failures = append(d.failures, failure{method: method, n: n, err: err})
+	}
+}
+
+// NewDriver instantiates a new in-memory driver.
+func NewDriver(opts ...DriverOption) (*Driver, error) {
+	d := &Driver{
+		calls: make(map[Method]int),
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	if d.db == nil {
+		d.db = NewDatabase()
+	}
+
+	return d, nil
+}
+
+// Database returns the database of the driver.
+func (d *Driver) Database() *Database {
+	return d.db
+}
+
+// Calls returns the number of calls of the given method, AnyMethod returns the number of all calls.
+func (d *Driver) Calls(method Method) int {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	return d.calls[method]
+}
+
+// InjectFailure makes the n-th call (starting at 1) of the given method fail with err, see WithFailure.
+func (d *Driver) InjectFailure(method Method, n int, err error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	d.failures = append(d.failures, failure{method: method, n: n, err: err})
+}
+
+// call counts a method call and returns the injected error for this call.
+func (d *Driver) call(method Method) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	d.calls[method]++
+	d.calls[AnyMethod]++
+	for _, f := range d.failures {
+		if f.n == d.calls[f.method] && (f.method == method || f.method == AnyMethod) {
+			return f.err
+		}
+	}
+	return nil
+}
+
+// Close is part of lightmigrate.MigrationDriver interface implementation.
+// A held lock is released, the database is kept.
+func (d *Driver) Close() error {
+	if err := d.call(MethodClose); err != nil {
+		return err
+	}
+	d.db.unlock(d)
+	return nil
+}
+
+// Lock is part of lightmigrate.MigrationDriver interface implementation.
+// Returns lightmigrate.ErrLocked if the database is locked by this or another driver.
+func (d *Driver) Lock() error {
+	if err := d.call(MethodLock); err != nil {
+		d.db.record(Event{Driver: d.name, Method: MethodLock, Err: err})
+		return err
+	}
+	_, err := d.db.tryLock(d)
+	return err
+}
+
+// LockContext is part of lightmigrate.MigrationDriverContext interface implementation.
+// If another driver holds the lock, LockContext waits until the lock is released or ctx is done.
+func (d *Driver) LockContext(ctx context.Context) error {
+	if err := d.call(MethodLock); err != nil {
+		d.db.record(Event{Driver: d.name, Method: MethodLock, Err: err})
+		return err
+	}
+
+	for {
+		released, err := d.db.tryLock(d)
+		if released == nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-released:
+		}
+	}
+}
+
+// Unlock is part of lightmigrate.MigrationDriver interface implementation.
+func (d *Driver) Unlock() error {
+	if err := d.call(MethodUnlock); err != nil {
+		d.db.record(Event{Driver: d.name, Method: MethodUnlock, Err: err})
+		return err
+	}
+	d.db.unlock(d)
+	return nil
+}
+
+// GetVersion is part of lightmigrate.MigrationDriver interface implementation.
+func (d *Driver) GetVersion() (version uint64, dirty bool, err error) {
+	if err = d.call(MethodGetVersion); err != nil {
+		return 0, false, err
+	}
+	version, dirty = d.db.Version()
+	return version, dirty, nil
+}
+
+// SetVersion is part of lightmigrate.MigrationDriver interface implementation.
+func (d *Driver) SetVersion(version uint64, dirty bool) error {
+	err := d.call(MethodSetVersion)
+
+	d.db.mux.Lock()
+	defer d.db.mux.Unlock()
+
+	if err == nil {
+		d.db.version = version
+		d.db.dirty = dirty
+	}
+	d.db.events = append(d.db.events, Event{
+		Driver:  d.name,
+		Method:  MethodSetVersion,
+		Version: version,
+		Dirty:   dirty,
+		Err:     err,
+	})
+
+	return err
+}
+
+// RunMigration is part of lightmigrate.MigrationDriver interface implementation.
+func (d *Driver) RunMigration(migration io.Reader) error {
+	return d.RunMigrationContext(context.Background(), migration)
+}
+
+// RunMigrationContext is part of lightmigrate.MigrationDriverContext interface implementation.
+func (d *Driver) RunMigrationContext(ctx context.Context, migration io.Reader) error {
+	err := d.call(MethodRunMigration)
+	if err == nil {
+		err = ctx.Err()
+	}
+
+	body, readErr := io.ReadAll(migration)
+	if err == nil {
+		err = readErr
+	}
+	d.db.record(Event{Driver: d.name, Method: MethodRunMigration, Migration: body, Err: err})
+
+	return err
+}
+
+// Reset is part of lightmigrate.MigrationDriver interface implementation.
+// The version, dirty state and history are cleared, the recorded events are kept.
+func (d *Driver) Reset() error {
+	err := d.call(MethodReset)
+
+	d.db.mux.Lock()
+	defer d.db.mux.Unlock()
+
+	if err == nil {
+		d.db.version = lightmigrate.NoMigrationVersion
+		d.db.dirty = false
+		d.db.history = nil
+	}
+	d.db.events = append(d.db.events, Event{Driver: d.name, Method: MethodReset, Err: err})
+
+	return err
+}
+
+// RecordApplied is part of lightmigrate.HistoryDriver interface implementation.
+func (d *Driver) RecordApplied(migration lightmigrate.AppliedMigration) error {
+	err := d.call(MethodRecordApplied)
+
+	d.db.mux.Lock()
+	defer d.db.mux.Unlock()
+
+	if err == nil {
+		d.db.history = append(d.db.history, migration)
+	}
+	d.db.events = append(d.db.events, Event{
+		Driver:  d.name,
+		Method:  MethodRecordApplied,
+		Version: migration.Version,
+		Err:     err,
+	})
+
+	return err
+}
+
+// ListApplied is part of lightmigrate.HistoryDriver interface implementation.
+func (d *Driver) ListApplied() ([]lightmigrate.AppliedMigration, error) {
+	if err := d.call(MethodListApplied); err != nil {
+		return nil, err
+	}
+	return d.db.History(), nil
+}
@@ -0,0 +1,225 @@
+package memdriver
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/h44z/lightmigrate"
+)
+
+func getTestSource(t *testing.T) lightmigrate.MigrationSource {
+	source, err := lightmigrate.NewMemorySource(map[string]string{
+		"1_init.up.sql":    "CREATE TABLE a",
+		"1_init.down.sql":  "DROP TABLE a",
+		"2_users.up.sql":   "CREATE TABLE b",
+		"2_users.down.sql": "DROP TABLE b",
+	})
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+	return source
+}
+
+func TestDriver_Interfaces(t *testing.T) {
+	var d interface{}
+	d, _ = NewDriver()
+
+	if _, ok := d.(lightmigrate.MigrationDriverContext); !ok {
+		t.Errorf("expected MigrationDriverContext implementation")
+	}
+	if _, ok := d.(lightmigrate.HistoryDriver); !ok {
+		t.Errorf("expected HistoryDriver implementation")
+	}
+}
+
+func TestDriver_Migrator(t *testing.T) {
+	d, _ := NewDriver(WithName("app"))
+	migrator, _ := lightmigrate.NewMigrator(getTestSource(t), d)
+
+	if err := migrator.Migrate(2); err != nil {
+		t.Fatalf("unexpected migration error: %v", err)
+	}
+	if err := migrator.Migrate(1); err != nil {
+		t.Fatalf("unexpected migration error: %v", err)
+	}
+
+	want := []string{"CREATE TABLE a", "CREATE TABLE b", "DROP TABLE b"}
+	if got := d.Database().Migrations(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected migrations: %v", got)
+	}
+
+	var methods []Method
+	for _, e := range d.Database().Events()[:6] {
+		if e.Driver != "app" {
+			t.Fatalf("unexpected driver name: %s", e.Driver)
+		}
+		methods = append(methods, e.Method)
+	}
+	wantMethods := []Method{MethodLock, MethodSetVersion, MethodRunMigration, MethodRecordApplied, MethodSetVersion, MethodSetVersion}
+	if !reflect.DeepEqual(methods, wantMethods) {
+		t.Fatalf("unexpected events: %v", methods)
+	}
+
+	if version, dirty := d.Database().Version(); version != 1 || dirty {
+		t.Fatalf("unexpected version: %d, %t", version, dirty)
+	}
+	if history := d.Database().History(); len(history) != 3 || history[2].Direction != lightmigrate.Down {
+		t.Fatalf("unexpected history: %v", history)
+	}
+	if d.Database().Locked() {
+		t.Fatalf("expected lock to be released")
+	}
+}
+
+func TestDriver_InjectFailure(t *testing.T) {
+	wantErr := errors.New("disk full")
+	d, _ := NewDriver(WithFailure(MethodRunMigration, 2, wantErr))
+	migrator, _ := lightmigrate.NewMigrator(getTestSource(t), d)
+
+	if err := migrator.Migrate(2); !errors.Is(err, wantErr) {
+		t.Fatalf("expected injected error, got: %v", err)
+	}
+	if version, dirty := d.Database().Version(); version != 2 || !dirty {
+		t.Fatalf("expected dirty version 2, got: %d, %t", version, dirty)
+	}
+	if got := d.Database().Migrations(); !reflect.DeepEqual(got, []string{"CREATE TABLE a"}) {
+		t.Fatalf("unexpected migrations: %v", got)
+	}
+
+	events := d.Database().Events()
+	var failed *Event
+	for i := range events {
+		if events[i].Err != nil {
+			failed = &events[i]
+		}
+	}
+	if failed == nil || failed.Method != MethodRunMigration || string(failed.Migration) != "CREATE TABLE b" {
+		t.Fatalf("expected failed migration event, got: %v", events)
+	}
+	if d.Calls(MethodRunMigration) != 2 {
+		t.Fatalf("unexpected number of calls: %d", d.Calls(MethodRunMigration))
+	}
+}
+
+func TestDriver_InjectFailure_AnyMethod(t *testing.T) {
+	wantErr := errors.New("connection lost")
+	d, _ := NewDriver()
+	d.InjectFailure(AnyMethod, 3, wantErr)
+
+	if _, _, err := d.GetVersion(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := d.SetVersion(1, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := d.Reset(); err != wantErr {
+		t.Fatalf("expected injected error, got: %v", err)
+	}
+	if version, _ := d.Database().Version(); version != 1 {
+		t.Fatalf("expected failed reset to keep version, got: %d", version)
+	}
+	if _, _, err := d.GetVersion(); err != nil {
+		t.Fatalf("unexpected error after injected failure: %v", err)
+	}
+	if d.Calls(AnyMethod) != 4 {
+		t.Fatalf("unexpected number of calls: %d", d.Calls(AnyMethod))
+	}
+}
+
+func TestDriver_Lock(t *testing.T) {
+	db := NewDatabase()
+	d1, _ := NewDriver(WithDatabase(db), WithName("first"))
+	d2, _ := NewDriver(WithDatabase(db), WithName("second"))
+
+	if err := d1.Lock(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := d1.Lock(); !errors.Is(err, lightmigrate.ErrLocked) {
+		t.Fatalf("expected ErrLocked, got: %v", err)
+	}
+	if err := d2.Lock(); !errors.Is(err, lightmigrate.ErrLocked) {
+		t.Fatalf("expected ErrLocked for second driver, got: %v", err)
+	}
+	if err := d2.Unlock(); err != nil || !db.Locked() {
+		t.Fatalf("expected unlock of second driver to keep lock, got: %v", err)
+	}
+	if err := d1.Close(); err != nil || db.Locked() {
+		t.Fatalf("expected close to release lock, got: %v", err)
+	}
+	if err := d2.Lock(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestDriver_LockContext(t *testing.T) {
+	db := NewDatabase()
+	d1, _ := NewDriver(WithDatabase(db), WithName("first"))
+	d2, _ := NewDriver(WithDatabase(db), WithName("second"))
+
+	_ = d1.Lock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := d2.LockContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got: %v", err)
+	}
+
+	locked := make(chan error)
+	go func() {
+		locked <- d2.LockContext(context.Background())
+	}()
+	time.Sleep(10 * time.Millisecond)
+	_ = d1.Unlock()
+
+	if err := <-locked; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !db.Locked() {
+		t.Fatalf("expected second driver to hold the lock")
+	}
+}
+
+func TestDriver_ConcurrentMigrators(t *testing.T) {
+	db := NewDatabase()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d, _ := NewDriver(WithDatabase(db))
+			migrator, _ := lightmigrate.NewMigrator(getTestSource(t), d)
+			errs <- migrator.Migrate(2)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil && !errors.Is(err, lightmigrate.ErrNoChange) {
+			t.Fatalf("unexpected migration error: %v", err)
+		}
+	}
+	if got := db.Migrations(); !reflect.DeepEqual(got, []string{"CREATE TABLE a", "CREATE TABLE b"}) {
+		t.Fatalf("expected migrations to be applied once, got: %v", got)
+	}
+}
+
+func TestDriver_RunMigrationContext_Canceled(t *testing.T) {
+	d, _ := NewDriver()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := d.RunMigrationContext(ctx, strings.NewReader("SELECT 1")); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled error, got: %v", err)
+	}
+	if len(d.Database().Migrations()) != 0 {
+		t.Fatalf("expected no successful migrations")
+	}
+}
@@ -36,6 +36,10 @@ type migration struct {
 	// Raw holds the raw location path to this migration in source.
 	// ReadUp and ReadDown will use this.
 	Raw string
+
+	// Section is true if the migration is a section of a file that
+	// contains both, the up and down migration.
+	Section bool
 }
 
 func newMigrations() *migrations {
@@ -1,12 +1,14 @@
 package lightmigrate
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"sync"
+	"time"
 )
 
 // NoMigrationVersion is a constant for version 0.
@@ -14,17 +16,40 @@ const NoMigrationVersion uint64 = 0
 
 // Migrator is a generic interface that provides compatibility with golang-migrate migrator.
 type Migrator interface {
+	// Migrate migrates the database up or down to the given version.
 	Migrate(version uint64) error
+
+	// MigrateContext is like Migrate but stops between migrations once ctx is done.
+	// The context is also passed to drivers that implement MigrationDriverContext.
+	MigrateContext(ctx context.Context, version uint64) error
+
+	// Steps applies n migrations forward if n is positive, or reverts n migrations if n is negative,
+	// relative to the current database version.
+	Steps(n int) error
+
+	// Force sets the database version without running any migrations and clears the dirty state.
+	// It can be used to recover from a failed migration after manually fixing the database.
+	// In dry run mode, the database is not modified.
+	Force(version uint64) error
+
+	// Plan returns all migrations that would be applied to migrate the database to the given version,
+	// without modifying the database.
+	Plan(version uint64) ([]PlannedMigration, error)
+
+	// Status returns a report that compares all migrations of the source with the database state.
+	Status() (*StatusReport, error)
 }
 
 // migrator contains the main logic for applying migrations.
 type migrator struct {
-	source   MigrationSource
-	driver   MigrationDriver
-	lock     sync.Mutex
-	shutdown chan bool
-	logger   Logger
-	verbose  bool
+	source     MigrationSource
+	driver     MigrationDriver
+	lock       sync.Mutex
+	shutdown   chan bool
+	logger     Logger
+	verbose    bool
+	dryRun     bool
+	outOfOrder bool
 }
 
 // MigratorOption is a function that can be used within the migrator constructor to
@@ -75,7 +100,80 @@ func WithVerboseLogging(verbose bool) MigratorOption {
 	}
 }
 
+// WithDryRun sets the dry run flag of the migrator. In dry run mode, migrations are only
+// logged but never applied to the database.
+func WithDryRun(dryRun bool) MigratorOption {
+	return func(m *migrator) {
+		m.dryRun = dryRun
+	}
+}
+
+// WithOutOfOrder sets the out of order flag of the migrator. If enabled, migrations with a lower version
+// than the current database version that have not been applied yet get applied before all other migrations.
+// Otherwise, ErrSkippedMigrations is returned if such migrations exist.
+// Out of order migrations are only detected if the driver implements HistoryDriver.
+func WithOutOfOrder(outOfOrder bool) MigratorOption {
+	return func(m *migrator) {
+		m.outOfOrder = outOfOrder
+	}
+}
+
 func (m *migrator) Migrate(version uint64) error {
+	return m.MigrateContext(context.Background(), version)
+}
+
+func (m *migrator) MigrateContext(ctx context.Context, version uint64) error {
+	return m.run(ctx, func(uint64) (uint64, error) {
+		return version, nil
+	})
+}
+
+func (m *migrator) Steps(n int) error {
+	return m.run(context.Background(), func(curVersion uint64) (uint64, error) {
+		return m.getStepsTargetVersion(curVersion, n)
+	})
+}
+
+func (m *migrator) Force(version uint64) error {
+	// avoid multiple concurrent runs of the migration
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if version != NoMigrationVersion && !m.isMigrationValid(version, Up) && !m.isMigrationValid(version, Down) {
+		return fmt.Errorf("invalid migration version %d", version)
+	}
+
+	// lock the database
+	err := m.lockDriver(context.Background())
+	if err != nil {
+		return err
+	}
+	defer m.driver.Unlock()
+
+	// get current version and dirty state for auditing purposes
+	curVersion, dirty, err := m.driver.GetVersion()
+	if err != nil {
+		return err
+	}
+
+	if m.dryRun {
+		m.logger.Printf("dry run: would force database version %d, previous version %d (dirty: %t)", version, curVersion, dirty)
+		return nil
+	}
+
+	m.logger.Printf("forcing database version %d, previous version %d (dirty: %t)", version, curVersion, dirty)
+
+	// the forced version has been applied manually
+	err = m.recordForced(version)
+	if err != nil {
+		return err
+	}
+
+	return m.driver.SetVersion(version, false)
+}
+
+// run locks the database and migrates from the current version to the version returned by targetFn.
+func (m *migrator) run(ctx context.Context, targetFn func(curVersion uint64) (uint64, error)) error {
 	// avoid multiple concurrent runs of the migration
 	m.lock.Lock()
 	defer m.lock.Unlock()
@@ -84,7 +182,7 @@ func (m *migrator) Migrate(version uint64) error {
 	m.shutdown = make(chan bool, 1)
 
 	// lock the database
-	err := m.driver.Lock()
+	err := m.lockDriver(ctx)
 	if err != nil {
 		return err
 	}
@@ -100,9 +198,42 @@ func (m *migrator) Migrate(version uint64) error {
 		return ErrDatabaseDirty
 	}
 
+	// make sure that already applied migrations have not been modified
+	err = m.verifyChecksums()
+	if err != nil {
+		return err
+	}
+
+	version, err := targetFn(curVersion)
+	if err != nil {
+		return err
+	}
+
+	if m.dryRun {
+		return m.logPlan(ctx, curVersion, version)
+	}
+
+	// validate the target before any migration is applied
+	err = m.validateTargetVersion(curVersion, version)
+	if err != nil {
+		return err
+	}
+
+	// apply migrations that have been merged late
+	if version >= curVersion {
+		outOfOrder, err := m.getOutOfOrderMigrations(curVersion)
+		if err != nil {
+			return err
+		}
+		err = m.applyOutOfOrderMigrations(ctx, outOfOrder)
+		if err != nil {
+			return err
+		}
+	}
+
 	// get all migrations
 	migrations := make(chan *migrationData)
-	err = m.GetMigrations(curVersion, version, migrations)
+	err = m.GetMigrations(ctx, curVersion, version, migrations)
 	if err == ErrNoChange {
 		m.logger.Printf("no database migration necessary")
 		return nil // nothing to do, no error
@@ -112,13 +243,71 @@ func (m *migrator) Migrate(version uint64) error {
 	}
 
 	// apply all migrations
-	return m.applyMigrations(migrations)
+	return m.applyMigrations(ctx, migrations)
+}
+
+// logPlan logs all migrations that would be applied, without modifying the database.
+func (m *migrator) logPlan(ctx context.Context, curVersion, version uint64) error {
+	planned, err := m.plan(ctx, curVersion, version)
+	if err != nil {
+		return err
+	}
+
+	if len(planned) == 0 {
+		m.logger.Printf("dry run: no database migration necessary")
+		return nil
+	}
+
+	for _, p := range planned {
+		m.logger.Printf("dry run: would apply %d, %s (%s), resulting version %d",
+			p.Version, p.Direction, p.Identifier, p.ResultingVersion)
+	}
+
+	return nil
+}
+
+// getStepsTargetVersion walks n versions forward (n > 0) or backward (n < 0) starting at the current version.
+// Each step must have a migration in the walked direction.
+func (m *migrator) getStepsTargetVersion(currentVersion uint64, n int) (uint64, error) {
+	direction := Up
+	steps := n
+	if n < 0 {
+		direction = Down
+		steps = -n
+	}
+
+	version := currentVersion
+	for i := 0; i < steps; i++ {
+		if direction == Down && version == NoMigrationVersion {
+			return 0, fmt.Errorf("%w: unable to revert %d migrations", ErrShortLimit, steps)
+		}
+		if direction == Down && !m.isMigrationValid(version, Down) {
+			return 0, fmt.Errorf("invalid migration %d, %s", version, Down)
+		}
+
+		next, err := m.getNextMigrationVersion(version, direction)
+		switch {
+		case errors.Is(err, os.ErrNotExist) && direction == Down:
+			next = NoMigrationVersion // reverting the first migration
+		case errors.Is(err, os.ErrNotExist):
+			return 0, fmt.Errorf("%w: unable to apply %d migrations", ErrShortLimit, steps)
+		case err != nil:
+			return 0, err
+		}
+		if direction == Up && !m.isMigrationValid(next, Up) {
+			return 0, fmt.Errorf("invalid migration %d, %s", next, Up)
+		}
+		version = next
+	}
+
+	return version, nil
 }
 
 // GetMigrations fills up a channel with migrations in the background. If the initialization fails, an
 // error is returned.
-// The migrations channel will be closed by this function.
-func (m *migrator) GetMigrations(currentVersion, targetVersion uint64, migrations chan<- *migrationData) error {
+// The migrations channel will be closed by this function. Once ctx is done, no further migrations will be emitted,
+// instead a migration containing the context error is emitted if the target version has not been reached.
+func (m *migrator) GetMigrations(ctx context.Context, currentVersion, targetVersion uint64, migrations chan<- *migrationData) error {
 	var direction = Up
 	if targetVersion < currentVersion {
 		direction = Down
@@ -130,8 +319,8 @@ func (m *migrator) GetMigrations(currentVersion, targetVersion uint64, migration
 		return ErrNoChange
 	}
 
-	if targetVersion != NoMigrationVersion && !m.isMigrationValid(targetVersion, direction) {
-		return fmt.Errorf("invalid target migration version %d", targetVersion)
+	if err := m.validateTargetVersion(currentVersion, targetVersion); err != nil {
+		return err
 	}
 
 	// read all migrations
@@ -160,6 +349,13 @@ func (m *migrator) GetMigrations(currentVersion, targetVersion uint64, migration
 			case <-m.shutdown: // avoid a blocked goroutine by checking if the migrator was shut down
 				running = false
 				continue
+			case <-ctx.Done(): // stop emitting migrations if the caller gave up
+				select {
+				case migrations <- &migrationData{error: ctx.Err()}: // the target version has not been reached
+				case <-m.shutdown:
+				}
+				running = false
+				continue
 			case migrations <- migration:
 			}
 
@@ -185,6 +381,18 @@ func (m *migrator) GetMigrations(currentVersion, targetVersion uint64, migration
 	return nil
 }
 
+// validateTargetVersion checks that a migration for the target version exists in the direction of the migration.
+func (m *migrator) validateTargetVersion(currentVersion, targetVersion uint64) error {
+	direction := Up
+	if targetVersion < currentVersion {
+		direction = Down
+	}
+	if targetVersion != currentVersion && targetVersion != NoMigrationVersion && !m.isMigrationValid(targetVersion, direction) {
+		return fmt.Errorf("invalid target migration version %d", targetVersion)
+	}
+	return nil
+}
+
 func (m *migrator) isMigrationValid(version uint64, direction Direction) bool {
 	var err error
 	var contents io.ReadCloser
@@ -230,8 +438,12 @@ func (m *migrator) getMigration(version uint64, direction Direction) *migrationD
 	}
 
 	targetVersion := version
-	if direction == Down {
-		targetVersion = targetVersion - 1
+	if direction == Down && err == nil {
+		// reverting a migration results in the previous version of the source, versions might not be contiguous
+		targetVersion, err = m.source.Prev(version)
+		if errors.Is(err, os.ErrNotExist) {
+			targetVersion, err = NoMigrationVersion, nil // no previous version, the first migration gets reverted
+		}
 	}
 
 	return &migrationData{
@@ -244,7 +456,7 @@ func (m *migrator) getMigration(version uint64, direction Direction) *migrationD
 	}
 }
 
-func (m *migrator) applyMigrations(migrations <-chan *migrationData) error {
+func (m *migrator) applyMigrations(ctx context.Context, migrations <-chan *migrationData) error {
 	defer func() {
 		m.shutdown <- true // on error - shutdown migration producer
 	}()
@@ -255,6 +467,12 @@ func (m *migrator) applyMigrations(migrations <-chan *migrationData) error {
 			return migration.Error()
 		}
 
+		// Stop cleanly between migrations if the context is done
+		if err := ctx.Err(); err != nil {
+			_ = migration.Contents.Close()
+			return err
+		}
+
 		// Set version with dirty state
 		err := m.driver.SetVersion(migration.TargetVersion, true)
 		if err != nil {
@@ -262,12 +480,28 @@ func (m *migrator) applyMigrations(migrations <-chan *migrationData) error {
 		}
 
 		// Apply migration
-		err = m.driver.RunMigration(migration.Contents)
+		startedAt := time.Now()
+		contents := newChecksumReader(migration.Contents)
+		if fn, ok := migration.Contents.(*funcBody); ok {
+			err = fn.fn(ctx, m.driver)
+		} else {
+			err = m.runMigration(ctx, contents)
+		}
 		if err != nil {
 			_ = migration.Contents.Close()
 			return err
 		}
+		checksum, err := contents.Checksum()
 		_ = migration.Contents.Close()
+		if err != nil {
+			return err
+		}
+
+		// Store migration history
+		err = m.recordApplied(migration, checksum, startedAt)
+		if err != nil {
+			return err
+		}
 
 		// Remove dirty state
 		err = m.driver.SetVersion(migration.TargetVersion, false)
@@ -282,3 +516,22 @@ func (m *migrator) applyMigrations(migrations <-chan *migrationData) error {
 
 	return nil
 }
+
+// lockDriver locks the database, using the context aware lock function if the driver supports it.
+func (m *migrator) lockDriver(ctx context.Context) error {
+	if d, ok := m.driver.(MigrationDriverContext); ok {
+		return d.LockContext(ctx)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return m.driver.Lock()
+}
+
+// runMigration applies a single migration, using the context aware run function if the driver supports it.
+func (m *migrator) runMigration(ctx context.Context, migration io.Reader) error {
+	if d, ok := m.driver.(MigrationDriverContext); ok {
+		return d.RunMigrationContext(ctx, migration)
+	}
+	return m.driver.RunMigration(migration)
+}
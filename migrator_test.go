@@ -2,6 +2,7 @@ package lightmigrate
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
 	"log"
@@ -58,7 +59,7 @@ func Test_migrator_GetMigrations(t *testing.T) {
 	m.source = s
 
 	migrations := make(chan *migrationData)
-	err := m.GetMigrations(NoMigrationVersion, 1, migrations)
+	err := m.GetMigrations(context.Background(), NoMigrationVersion, 1, migrations)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -82,7 +83,7 @@ func Test_migrator_GetMigrations_NoChange(t *testing.T) {
 	m.source = s
 
 	migrations := make(chan *migrationData)
-	err := m.GetMigrations(1, 1, migrations)
+	err := m.GetMigrations(context.Background(), 1, 1, migrations)
 	if err != ErrNoChange {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -95,7 +96,7 @@ func Test_migrator_GetMigrations_Shutdown(t *testing.T) {
 	m.shutdown = make(chan bool, 1)
 
 	migrations := make(chan *migrationData)
-	err := m.GetMigrations(NoMigrationVersion, 3, migrations)
+	err := m.GetMigrations(context.Background(), NoMigrationVersion, 3, migrations)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -129,7 +130,7 @@ func Test_migrator_GetMigrations_MultiUp(t *testing.T) {
 	m.source = s
 
 	migrations := make(chan *migrationData)
-	err := m.GetMigrations(1, 3, migrations)
+	err := m.GetMigrations(context.Background(), 1, 3, migrations)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -153,7 +154,7 @@ func Test_migrator_GetMigrations_MultiUpZero(t *testing.T) {
 	m.source = s
 
 	migrations := make(chan *migrationData)
-	err := m.GetMigrations(NoMigrationVersion, 3, migrations)
+	err := m.GetMigrations(context.Background(), NoMigrationVersion, 3, migrations)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -177,7 +178,7 @@ func Test_migrator_GetMigrations_MultiDown(t *testing.T) {
 	m.source = s
 
 	migrations := make(chan *migrationData)
-	err := m.GetMigrations(3, 1, migrations)
+	err := m.GetMigrations(context.Background(), 3, 1, migrations)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -201,7 +202,7 @@ func Test_migrator_GetMigrations_MultiDownZero(t *testing.T) {
 	m.source = s
 
 	migrations := make(chan *migrationData)
-	err := m.GetMigrations(3, NoMigrationVersion, migrations)
+	err := m.GetMigrations(context.Background(), 3, NoMigrationVersion, migrations)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -225,7 +226,7 @@ func Test_migrator_GetMigrations_WrongTarget(t *testing.T) {
 	m.source = s
 
 	migrations := make(chan *migrationData)
-	err := m.GetMigrations(NoMigrationVersion, 3, migrations)
+	err := m.GetMigrations(context.Background(), NoMigrationVersion, 3, migrations)
 	if err == nil {
 		t.Fatalf("expected error: %v", err)
 	}
@@ -298,7 +299,7 @@ func Test_migrator_applyMigrations(t *testing.T) {
 	migrations <- &migrationData{Version: 2, Contents: io.NopCloser(bytes.NewReader([]byte("test2")))}
 	close(migrations)
 
-	err := m.applyMigrations(migrations)
+	err := m.applyMigrations(context.Background(), migrations)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -314,7 +315,7 @@ func Test_migrator_applyMigrations_Verbose(t *testing.T) {
 	migrations <- &migrationData{Version: 2, Contents: io.NopCloser(bytes.NewReader([]byte("test2")))}
 	close(migrations)
 
-	err := m.applyMigrations(migrations)
+	err := m.applyMigrations(context.Background(), migrations)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -327,7 +328,7 @@ func Test_migrator_applyMigrations_MigrationError(t *testing.T) {
 	migrations := make(chan *migrationData, 1)
 	migrations <- &migrationData{error: ErrVersionNotAllowed}
 
-	err := m.applyMigrations(migrations)
+	err := m.applyMigrations(context.Background(), migrations)
 	if err != ErrVersionNotAllowed {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -408,3 +409,358 @@ func Test_migrator_isMigrationValid_Invalid(t *testing.T) {
 		t.Fatalf("expected migration to be invalid, got %t", got)
 	}
 }
+
+func Test_migrator_MigrateContext(t *testing.T) {
+	m := getTestMigrator()
+	s, _ := test.NewMockSource(1, 3)
+	m.source = s
+	d, _ := test.NewMockContextDriver()
+	m.driver = d
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+	err := m.MigrateContext(ctx, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.Version != 2 {
+		t.Fatalf("expected version 2, got: %d", d.Version)
+	}
+	if len(d.Contexts) != 3 { // one lock and two migrations
+		t.Fatalf("expected 3 context calls, got: %d", len(d.Contexts))
+	}
+	for _, c := range d.Contexts {
+		if c != ctx {
+			t.Fatalf("context was not passed to driver")
+		}
+	}
+}
+
+func Test_migrator_MigrateContext_Canceled(t *testing.T) {
+	m := getTestMigrator()
+	s, _ := test.NewMockSource(1, 3)
+	m.source = s
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := m.MigrateContext(ctx, 2)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled error, got: %v", err)
+	}
+	if v := m.driver.(*test.MockDriver).Version; v != NoMigrationVersion {
+		t.Fatalf("expected no migration to be applied, got version: %d", v)
+	}
+}
+
+func Test_migrator_applyMigrations_Canceled(t *testing.T) {
+	m := getTestMigrator()
+	m.shutdown = make(chan bool, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	migrations := make(chan *migrationData, 2)
+	migrations <- &migrationData{Version: 1, TargetVersion: 1, Contents: io.NopCloser(bytes.NewReader([]byte("test1")))}
+	migrations <- &migrationData{Version: 2, TargetVersion: 2, Contents: io.NopCloser(bytes.NewReader([]byte("test2")))}
+	close(migrations)
+
+	m.driver = &cancelingDriver{MockDriver: m.driver.(*test.MockDriver), cancel: cancel}
+
+	err := m.applyMigrations(ctx, migrations)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled error, got: %v", err)
+	}
+	if v := m.driver.(*cancelingDriver).Version; v != 1 {
+		t.Fatalf("expected to stop after version 1, got version: %d", v)
+	}
+}
+
+func Test_migrator_MigrateContext_CanceledLastMigration(t *testing.T) {
+	m := getTestMigrator()
+	ctx, cancel := context.WithCancel(context.Background())
+	m.driver = &cancelingDriver{MockDriver: m.driver.(*test.MockDriver), cancel: cancel, version: 2}
+
+	err := m.MigrateContext(ctx, 2)
+	if err != nil {
+		t.Fatalf("expected completed migration to succeed, got: %v", err)
+	}
+	if v := m.driver.(*cancelingDriver).Version; v != 2 {
+		t.Fatalf("expected version 2, got: %d", v)
+	}
+}
+
+func Test_migrator_GetMigrations_Canceled(t *testing.T) {
+	m := getTestMigrator()
+	m.shutdown = make(chan bool, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	migrations := make(chan *migrationData)
+	if err := m.GetMigrations(ctx, NoMigrationVersion, 2, migrations); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var err error
+	for mig := range migrations {
+		if mig.Error() != nil {
+			err = mig.Error()
+		}
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled migration, got: %v", err)
+	}
+}
+
+type ctxKey struct{}
+
+// cancelingDriver cancels the context while running the migration of the given version, or the first
+// migration if no version is set.
+type cancelingDriver struct {
+	*test.MockDriver
+	cancel  context.CancelFunc
+	version uint64
+}
+
+func (c *cancelingDriver) RunMigration(migration io.Reader) error {
+	if c.version == NoMigrationVersion || c.Version == c.version {
+		c.cancel()
+	}
+	return c.MockDriver.RunMigration(migration)
+}
+
+func Test_migrator_Steps_Up(t *testing.T) {
+	m := getTestMigrator()
+	s, _ := test.NewMockSource(1, 3)
+	m.source = s
+
+	err := m.Steps(2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v := m.driver.(*test.MockDriver).Version; v != 2 {
+		t.Fatalf("expected version 2, got: %d", v)
+	}
+
+	err = m.Steps(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v := m.driver.(*test.MockDriver).Version; v != 3 {
+		t.Fatalf("expected version 3, got: %d", v)
+	}
+}
+
+func Test_migrator_Steps_Down(t *testing.T) {
+	m := getTestMigrator()
+	s, _ := test.NewMockSource(1, 3)
+	m.source = s
+	m.driver.(*test.MockDriver).Version = 3
+
+	err := m.Steps(-1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v := m.driver.(*test.MockDriver).Version; v != 2 {
+		t.Fatalf("expected version 2, got: %d", v)
+	}
+
+	err = m.Steps(-2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v := m.driver.(*test.MockDriver).Version; v != NoMigrationVersion {
+		t.Fatalf("expected version 0, got: %d", v)
+	}
+}
+
+func Test_migrator_Steps_NoChange(t *testing.T) {
+	m := getTestMigrator()
+	m.driver.(*test.MockDriver).Version = 1
+
+	err := m.Steps(0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v := m.driver.(*test.MockDriver).Version; v != 1 {
+		t.Fatalf("expected version 1, got: %d", v)
+	}
+}
+
+func Test_migrator_Steps_ShortLimit(t *testing.T) {
+	m := getTestMigrator()
+	s, _ := test.NewMockSource(1, 3)
+	m.source = s
+	m.driver.(*test.MockDriver).Version = 2
+
+	err := m.Steps(2)
+	if !errors.Is(err, ErrShortLimit) {
+		t.Fatalf("expected ErrShortLimit error, got: %v", err)
+	}
+
+	err = m.Steps(-3)
+	if !errors.Is(err, ErrShortLimit) {
+		t.Fatalf("expected ErrShortLimit error, got: %v", err)
+	}
+	if v := m.driver.(*test.MockDriver).Version; v != 2 {
+		t.Fatalf("expected version to be unchanged, got: %d", v)
+	}
+}
+
+func Test_migrator_Steps_MissingMigration(t *testing.T) {
+	m := getTestMigrator()
+	source := NewFuncSource()
+	noop := func(ctx context.Context, driver MigrationDriver) error { return nil }
+	_ = source.Register(1, "first", noop, noop)
+	_ = source.Register(2, "irreversible", noop, nil)
+	m.source = source
+	d := m.driver.(*test.MockDriver)
+	d.Version = 2
+
+	if err := m.Steps(-1); err == nil || errors.Is(err, ErrShortLimit) {
+		t.Fatalf("expected error for missing down migration, got: %v", err)
+	}
+	if d.Version != 2 {
+		t.Fatalf("expected version to be unchanged, got: %d", d.Version)
+	}
+
+	m.source, _ = NewMemorySource(map[string]string{
+		"1_first.up.sql":       "1",
+		"1_first.down.sql":     "1",
+		"2_down-only.down.sql": "2",
+	})
+	d.Version = 1
+
+	if err := m.Steps(1); err == nil || errors.Is(err, ErrShortLimit) {
+		t.Fatalf("expected error for missing up migration, got: %v", err)
+	}
+	if d.Version != 1 {
+		t.Fatalf("expected version to be unchanged, got: %d", d.Version)
+	}
+}
+
+func Test_migrator_Force(t *testing.T) {
+	m := getTestMigrator()
+	d := m.driver.(*test.MockDriver)
+	d.Version = 2
+	d.Dirty = true
+
+	err := m.Force(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.Version != 1 || d.Dirty {
+		t.Fatalf("expected clean version 1, got: %d (dirty: %t)", d.Version, d.Dirty)
+	}
+
+	err = m.Force(NoMigrationVersion)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.Version != NoMigrationVersion || d.Dirty {
+		t.Fatalf("expected clean version 0, got: %d (dirty: %t)", d.Version, d.Dirty)
+	}
+}
+
+func Test_migrator_Force_InvalidVersion(t *testing.T) {
+	m := getTestMigrator()
+	d := m.driver.(*test.MockDriver)
+	d.Version = 2
+	d.Dirty = true
+
+	err := m.Force(5)
+	if err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+	if d.Version != 2 || !d.Dirty {
+		t.Fatalf("expected unchanged dirty version 2, got: %d (dirty: %t)", d.Version, d.Dirty)
+	}
+}
+
+func Test_migrator_Force_DriverError(t *testing.T) {
+	m := getTestMigrator()
+	m.driver.(*test.MockDriver).Error = errors.New("lockerror")
+
+	err := m.Force(1)
+	if err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+}
+
+func Test_migrator_Force_DryRun(t *testing.T) {
+	m, d := getTestHistoryMigrator(1, 2)
+	m.dryRun = true
+	d.Version = 2
+	d.Dirty = true
+
+	err := m.Force(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.Version != 2 || !d.Dirty {
+		t.Fatalf("expected unchanged dirty version 2, got: %d (dirty: %t)", d.Version, d.Dirty)
+	}
+	if len(d.History) != 0 {
+		t.Fatalf("expected no history entries, got: %v", d.History)
+	}
+}
+
+func TestWithDryRun(t *testing.T) {
+	m := &migrator{}
+
+	WithDryRun(true)(m)
+	if m.dryRun != true {
+		t.Fatalf("failed to set dry run flag")
+	}
+}
+
+func Test_migrator_Migrate_DryRun(t *testing.T) {
+	m := getTestMigrator()
+	s, _ := test.NewMockSource(1, 3)
+	m.source = s
+	m.dryRun = true
+
+	err := m.Migrate(3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v := m.driver.(*test.MockDriver).Version; v != NoMigrationVersion {
+		t.Fatalf("expected database to be unchanged, got version: %d", v)
+	}
+
+	err = m.Steps(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v := m.driver.(*test.MockDriver).Version; v != NoMigrationVersion {
+		t.Fatalf("expected database to be unchanged, got version: %d", v)
+	}
+}
+
+func Test_migrator_getMigration_Down_Sparse(t *testing.T) {
+	m := getTestMigrator()
+	m.source = getTestSource(t, "sparse-migrations")
+
+	got := m.getMigration(30, Down)
+	if got.Error() != nil {
+		t.Fatalf("unexpected error: %v", got.Error())
+	}
+	if got.TargetVersion != 20 {
+		t.Fatalf("unexpected target version: %d", got.TargetVersion)
+	}
+
+	got = m.getMigration(10, Down)
+	if got.Error() != nil {
+		t.Fatalf("unexpected error: %v", got.Error())
+	}
+	if got.TargetVersion != NoMigrationVersion {
+		t.Fatalf("unexpected target version: %d", got.TargetVersion)
+	}
+}
+
+func Test_migrator_getMigration_Down_PrevError(t *testing.T) {
+	m := getTestMigrator()
+	m.source.(*test.MockSource).Error = errors.New("preverror")
+
+	got := m.getMigration(2, Down)
+	if got.Error() == nil {
+		t.Fatalf("expected error, got nil")
+	}
+}
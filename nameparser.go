@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // ErrParse describes a filename parsing error.
@@ -14,6 +16,99 @@ var ErrParse = fmt.Errorf("no match")
 //  123_name.down.ext
 var Regex = regexp.MustCompile(`^([0-9]+)_(.*)\.(` + string(Down) + `|` + string(Up) + `)\.(.*)$`)
 
+// SingleFileRegex matches the following pattern for files containing up and down sections:
+//  123_name.ext
+var SingleFileRegex = regexp.MustCompile(`^([0-9]+)_(.*)\.(.*)$`)
+
+// VersionDirectoryRegex matches the following pattern for version directories:
+//  123_name
+var VersionDirectoryRegex = regexp.MustCompile(`^([0-9]+)_(.*)$`)
+
+// FlywayRegex matches the following pattern:
+//  V123__name.ext
+//  U123__name.ext
+var FlywayRegex = regexp.MustCompile(`^([VU])([0-9]+)__(.*)\.(.*)$`)
+
+// TimestampRegex matches the following patterns, the direction is optional:
+//  20220131120000_name.up.ext
+//  2022-01-31-120000_name.down.ext
+//  2022_01_31_120000_name.ext
+// Names of files with direction may contain dots, names of files without direction end at the first dot.
+var TimestampRegex = regexp.MustCompile(`^([0-9]{4})[-_]?([0-9]{2})[-_]?([0-9]{2})[-_T]?([0-9]{2})[-_]?([0-9]{2})[-_]?([0-9]{2})[-_](?:(.*)\.(` + string(Down) + `|` + string(Up) + `)|(.*?))\.(.*)$`)
+
+// FileNameParser parses a migration file name. If the file contains the up and down migration
+// in sections, the direction must be empty. Files that are no migrations must return an error.
+type FileNameParser func(name string) (version uint64, identifier string, dir Direction, err error)
+
+// DefaultFileNameParser parses file names matching the Regex pattern.
+func DefaultFileNameParser(name string) (version uint64, identifier string, dir Direction, err error) {
+	m, err := parseFileName(name)
+	if err != nil {
+		return 0, "", "", err
+	}
+	return m.Version, m.Identifier, m.Direction, nil
+}
+
+// FlywayFileNameParser parses Flyway versioned (V1__name.sql) and undo (U1__name.sql) migrations.
+// Dotted versions and repeatable migrations are not supported.
+func FlywayFileNameParser(name string) (version uint64, identifier string, dir Direction, err error) {
+	m := FlywayRegex.FindStringSubmatch(name)
+	if len(m) != 5 {
+		return 0, "", "", ErrParse
+	}
+	version, err = strconv.ParseUint(m[2], 10, 64)
+	if err != nil {
+		return 0, "", "", err
+	}
+	if version == NoMigrationVersion {
+		return 0, "", "", ErrVersionNotAllowed
+	}
+
+	dir = Up
+	if m[1] == "U" {
+		dir = Down
+	}
+	return version, m[3], dir, nil
+}
+
+// GooseFileNameParser parses goose migrations (20170506082420_name.sql or 00001_name.sql). Goose migrations
+// contain both directions, use it together with WithSectionMarkers("-- +goose Up", "-- +goose Down"). Without
+// the goose markers, NewFsSource fails with ErrNoSections.
+func GooseFileNameParser(name string) (version uint64, identifier string, dir Direction, err error) {
+	if !strings.HasSuffix(name, ".sql") {
+		return 0, "", "", ErrParse
+	}
+	m, err := parseSingleFileName(name)
+	if err != nil {
+		return 0, "", "", err
+	}
+	return m.Version, m.Identifier, "", nil
+}
+
+// TimestampFileNameParser parses file names starting with a timestamp, see TimestampRegex. The version is
+// the timestamp in the format YYYYMMDDhhmmss. File names without direction contain both directions in sections.
+func TimestampFileNameParser(name string) (version uint64, identifier string, dir Direction, err error) {
+	m := TimestampRegex.FindStringSubmatch(name)
+	if len(m) != 11 {
+		return 0, "", "", ErrParse
+	}
+
+	timestamp := strings.Join(m[1:7], "")
+	if _, err := time.Parse("20060102150405", timestamp); err != nil {
+		return 0, "", "", err
+	}
+	version, err = strconv.ParseUint(timestamp, 10, 64)
+	if err != nil {
+		return 0, "", "", err
+	}
+
+	identifier = m[7]
+	if m[8] == "" {
+		identifier = m[9] // no direction
+	}
+	return version, identifier, Direction(m[8]), nil
+}
+
 // parseFileName returns migration for matching Regex pattern.
 func parseFileName(raw string) (*migration, error) {
 	m := Regex.FindStringSubmatch(raw)
@@ -34,3 +129,25 @@ func parseFileName(raw string) (*migration, error) {
 	}
 	return nil, ErrParse
 }
+
+// parseSingleFileName returns migration for matching SingleFileRegex pattern.
+// The direction of the returned migration is empty, it contains both directions.
+func parseSingleFileName(raw string) (*migration, error) {
+	m := SingleFileRegex.FindStringSubmatch(raw)
+	if len(m) == 4 {
+		versionUint64, err := strconv.ParseUint(m[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		if versionUint64 == NoMigrationVersion {
+			return nil, ErrVersionNotAllowed
+		}
+		return &migration{
+			Version:    versionUint64,
+			Identifier: m[2],
+			Raw:        raw,
+			Section:    true,
+		}, nil
+	}
+	return nil, ErrParse
+}
@@ -146,3 +146,145 @@ func TestParseFileName(t *testing.T) {
 		})
 	}
 }
+
+func TestParseSingleFileName(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		want     *migration
+		wantErr  bool
+	}{
+		{
+			name:     "1_foobar.sql",
+			filename: "1_foobar.sql",
+			wantErr:  false,
+			want: &migration{
+				Version:    1,
+				Identifier: "foobar",
+				Raw:        "1_foobar.sql",
+				Section:    true,
+			},
+		},
+		{
+			name:     "20170506082420_create_table.sql",
+			filename: "20170506082420_create_table.sql",
+			wantErr:  false,
+			want: &migration{
+				Version:    20170506082420,
+				Identifier: "create_table",
+				Raw:        "20170506082420_create_table.sql",
+				Section:    true,
+			},
+		},
+		{
+			name:     "0_foobar.sql",
+			filename: "0_foobar.sql",
+			wantErr:  true,
+			want:     nil,
+		},
+		{
+			name:     "18446744073709551616_foobar.sql",
+			filename: "18446744073709551616_foobar.sql",
+			wantErr:  true,
+			want:     nil,
+		},
+		{
+			name:     "foobar.sql",
+			filename: "foobar.sql",
+			wantErr:  true,
+			want:     nil,
+		},
+		{
+			name:     "1_foobar",
+			filename: "1_foobar",
+			wantErr:  true,
+			want:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSingleFileName(tt.filename)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseSingleFileName() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSingleFileName() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type fileNameParserResult struct {
+	version    uint64
+	identifier string
+	dir        Direction
+}
+
+func testFileNameParser(t *testing.T, parser FileNameParser, tests map[string]*fileNameParserResult) {
+	for filename, want := range tests {
+		t.Run(filename, func(t *testing.T) {
+			version, identifier, dir, err := parser(filename)
+			if (err != nil) != (want == nil) {
+				t.Fatalf("parser error = %v, want %v", err, want)
+			}
+			if want == nil {
+				return
+			}
+			got := &fileNameParserResult{version: version, identifier: identifier, dir: dir}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("parser got = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestDefaultFileNameParser(t *testing.T) {
+	testFileNameParser(t, DefaultFileNameParser, map[string]*fileNameParserResult{
+		"1_foobar.up.sql":   {version: 1, identifier: "foobar", dir: Up},
+		"1_foobar.down.sql": {version: 1, identifier: "foobar", dir: Down},
+		"1_foobar.sql":      nil,
+		"0_foobar.up.sql":   nil,
+	})
+}
+
+func TestFlywayFileNameParser(t *testing.T) {
+	testFileNameParser(t, FlywayFileNameParser, map[string]*fileNameParserResult{
+		"V1__create_table.sql":                {version: 1, identifier: "create_table", dir: Up},
+		"U1__create_table.sql":                {version: 1, identifier: "create_table", dir: Down},
+		"V0020__add column.sql":               {version: 20, identifier: "add column", dir: Up},
+		"V0__zero.sql":                        nil,
+		"V1.1__dotted.sql":                    nil,
+		"R__repeatable.sql":                   nil,
+		"B1__baseline.sql":                    nil,
+		"V1_single_underline.sql":             nil,
+		"V18446744073709551616__overflow.sql": nil,
+	})
+}
+
+func TestGooseFileNameParser(t *testing.T) {
+	testFileNameParser(t, GooseFileNameParser, map[string]*fileNameParserResult{
+		"20170506082420_create_table.sql": {version: 20170506082420, identifier: "create_table"},
+		"00001_create_table.sql":          {version: 1, identifier: "create_table"},
+		"00001_create_table.go":           nil,
+		"00000_zero.sql":                  nil,
+		"create_table.sql":                nil,
+	})
+}
+
+func TestTimestampFileNameParser(t *testing.T) {
+	testFileNameParser(t, TimestampFileNameParser, map[string]*fileNameParserResult{
+		"20220131120000_create.up.sql":        {version: 20220131120000, identifier: "create", dir: Up},
+		"2022-01-31-120000_create.down.sql":   {version: 20220131120000, identifier: "create", dir: Down},
+		"2014_10_12_000000_create_users.php":  {version: 20141012000000, identifier: "create_users"},
+		"2022-01-31T12-00-00_create.sql":      {version: 20220131120000, identifier: "create"},
+		"20220131120000-create.up.json.gz":    {version: 20220131120000, identifier: "create", dir: Up},
+		"20220131120000_a.b.up.sql":           {version: 20220131120000, identifier: "a.b", dir: Up},
+		"20220131120000_a.b.down.sql.gz":      {version: 20220131120000, identifier: "a.b", dir: Down},
+		"20220131120000_create.json.gz":       {version: 20220131120000, identifier: "create"},
+		"20221331120000_invalid_month.up.sql": nil,
+		"20220131250000_invalid_hour.up.sql":  nil,
+		"1_not_a_timestamp.up.sql":            nil,
+		"20220131120000_no_extension":         nil,
+	})
+}
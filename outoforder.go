@@ -0,0 +1,70 @@
+package lightmigrate
+
+import (
+	"context"
+	"errors"
+	"os"
+)
+
+// getOutOfOrderMigrations returns all up migrations with a version lower than or equal to the current version
+// that are missing in the migration history. The database version will not be changed by those migrations.
+// Versions below the history baseline have been migrated without history support and are never skipped.
+// If the history is empty, the database has been migrated without history support and no migrations are returned.
+func (m *migrator) getOutOfOrderMigrations(currentVersion uint64) ([]*migrationData, error) {
+	applied, baseline, ok, err := m.getAppliedVersions()
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	skipped := make([]uint64, 0)
+	version, err := m.source.First()
+	for err == nil && version <= currentVersion {
+		_, isApplied := applied[version]
+		if !isApplied && version > baseline && m.isMigrationValid(version, Up) {
+			skipped = append(skipped, version)
+		}
+		version, err = m.source.Next(version)
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if len(skipped) == 0 {
+		return nil, nil
+	}
+
+	if !m.outOfOrder {
+		return nil, ErrSkippedMigrations{Versions: skipped}
+	}
+
+	migrations := make([]*migrationData, 0, len(skipped))
+	for _, version := range skipped {
+		migration := m.getMigration(version, Up)
+		migration.TargetVersion = currentVersion // keep the current database version
+		migrations = append(migrations, migration)
+	}
+
+	return migrations, nil
+}
+
+// applyOutOfOrderMigrations applies all migrations that have been skipped by the regular migration order.
+func (m *migrator) applyOutOfOrderMigrations(ctx context.Context, migrationList []*migrationData) error {
+	if len(migrationList) == 0 {
+		return nil
+	}
+
+	migrations := make(chan *migrationData, len(migrationList))
+	for _, migration := range migrationList {
+		migrations <- migration
+	}
+	close(migrations)
+
+	// use a separate shutdown channel, there is no background producer
+	shutdown := m.shutdown
+	m.shutdown = make(chan bool, 1)
+	defer func() {
+		m.shutdown = shutdown
+	}()
+
+	return m.applyMigrations(ctx, migrations)
+}
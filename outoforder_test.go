@@ -0,0 +1,220 @@
+package lightmigrate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWithOutOfOrder(t *testing.T) {
+	m := &migrator{}
+
+	WithOutOfOrder(true)(m)
+	if m.outOfOrder != true {
+		t.Fatalf("failed to set out of order flag")
+	}
+}
+
+func getTestOutOfOrderMigrator() (*migrator, *mockHistoryDriver) {
+	m, d := getTestHistoryMigrator(1, 4)
+	d.Version = 3
+	d.History = []AppliedMigration{
+		{Version: 1, Direction: Up},
+		{Version: 3, Direction: Up},
+	}
+
+	return m, d
+}
+
+func Test_migrator_Migrate_OutOfOrderStrict(t *testing.T) {
+	m, d := getTestOutOfOrderMigrator()
+
+	err := m.Migrate(4)
+	var skipped ErrSkippedMigrations
+	if !errors.As(err, &skipped) {
+		t.Fatalf("expected ErrSkippedMigrations error, got: %v", err)
+	}
+	if !reflect.DeepEqual(skipped.Versions, []uint64{2}) {
+		t.Fatalf("expected skipped version 2, got: %v", skipped.Versions)
+	}
+	if d.Version != 3 || len(d.History) != 2 {
+		t.Fatalf("expected database to be unchanged, got version: %d", d.Version)
+	}
+
+	err = m.Migrate(3)
+	if !errors.As(err, &skipped) {
+		t.Fatalf("expected ErrSkippedMigrations error, got: %v", err)
+	}
+}
+
+func Test_migrator_Migrate_OutOfOrder(t *testing.T) {
+	m, d := getTestOutOfOrderMigrator()
+	m.outOfOrder = true
+
+	err := m.Migrate(4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.Version != 4 {
+		t.Fatalf("expected version 4, got: %d", d.Version)
+	}
+
+	versions := make([]uint64, 0)
+	for _, h := range d.History {
+		versions = append(versions, h.Version)
+	}
+	if !reflect.DeepEqual(versions, []uint64{1, 3, 2, 4}) {
+		t.Fatalf("unexpected history: %v", versions)
+	}
+}
+
+func Test_migrator_Migrate_OutOfOrderInvalidTarget(t *testing.T) {
+	m, d := getTestOutOfOrderMigrator()
+	m.outOfOrder = true
+
+	err := m.Migrate(10)
+	if err == nil {
+		t.Fatalf("expected invalid target error")
+	}
+	if d.Version != 3 || len(d.History) != 2 {
+		t.Fatalf("expected database to be unchanged, got version %d and history: %v", d.Version, d.History)
+	}
+}
+
+func Test_migrator_Migrate_OutOfOrderNoChange(t *testing.T) {
+	m, d := getTestOutOfOrderMigrator()
+	m.outOfOrder = true
+
+	err := m.Migrate(3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.Version != 3 || d.Dirty {
+		t.Fatalf("expected clean version 3, got: %d (dirty: %t)", d.Version, d.Dirty)
+	}
+	if len(d.History) != 3 || d.History[2].Version != 2 {
+		t.Fatalf("expected version 2 to be applied, got: %v", d.History)
+	}
+}
+
+func Test_migrator_Migrate_OutOfOrderDown(t *testing.T) {
+	m, d := getTestOutOfOrderMigrator()
+
+	err := m.Migrate(1) // skipped migrations are ignored when migrating down
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.Version != 1 {
+		t.Fatalf("expected version 1, got: %d", d.Version)
+	}
+}
+
+func Test_migrator_Plan_OutOfOrder(t *testing.T) {
+	m, _ := getTestOutOfOrderMigrator()
+	m.outOfOrder = true
+
+	got, err := m.Plan(4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []PlannedMigration{
+		{Version: 2, Direction: Up, ResultingVersion: 3},
+		{Version: 4, Direction: Up, ResultingVersion: 4},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected plan: %v, got: %v", want, got)
+	}
+}
+
+func Test_migrator_getOutOfOrderMigrations_EmptyHistory(t *testing.T) {
+	m, d := getTestHistoryMigrator(1, 4)
+	d.Version = 3 // migrated without history support
+
+	got, err := m.getOutOfOrderMigrations(3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("expected no migrations, got: %v", got)
+	}
+}
+
+func Test_migrator_getOutOfOrderMigrations_NoHistory(t *testing.T) {
+	m := getTestMigrator()
+
+	got, err := m.getOutOfOrderMigrations(2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("expected no migrations, got: %v", got)
+	}
+}
+
+func Test_migrator_Migrate_OutOfOrderAfterForce(t *testing.T) {
+	for _, outOfOrder := range []bool{false, true} {
+		m, d := getTestHistoryMigrator(1, 4)
+		m.outOfOrder = outOfOrder
+		// migration 3 failed and has been fixed manually
+		d.Version = 3
+		d.Dirty = true
+		d.History = []AppliedMigration{
+			{Version: 1, Direction: Up},
+			{Version: 2, Direction: Up},
+		}
+
+		err := m.Force(3)
+		if err != nil {
+			t.Fatalf("unexpected force error: %v", err)
+		}
+		if len(d.History) != 3 || d.History[2].Version != 3 || d.History[2].Direction != Up {
+			t.Fatalf("expected forced version to be recorded, got: %v", d.History)
+		}
+
+		err = m.Migrate(4)
+		if err != nil {
+			t.Fatalf("unexpected migration error (out of order: %t): %v", outOfOrder, err)
+		}
+		wantVersions := []uint64{1, 2, 3, 4}
+		for i, h := range d.History {
+			if h.Version != wantVersions[i] {
+				t.Fatalf("expected migration 3 not to be applied again, got: %v", d.History)
+			}
+		}
+		if d.Version != 4 || d.Dirty {
+			t.Fatalf("expected clean version 4, got: %d (dirty: %t)", d.Version, d.Dirty)
+		}
+	}
+}
+
+func Test_migrator_Migrate_OutOfOrderHistoryBaseline(t *testing.T) {
+	m, d := getTestHistoryMigrator(1, 4)
+	// versions 1 and 2 have been applied before the history was kept
+	d.Version = 3
+	d.History = []AppliedMigration{
+		{Version: 3, Direction: Up},
+	}
+
+	err := m.Migrate(4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(d.History) != 2 || d.History[1].Version != 4 {
+		t.Fatalf("expected only version 4 to be applied, got: %v", d.History)
+	}
+}
+
+func Test_historyBaseline(t *testing.T) {
+	history := []AppliedMigration{
+		{Version: 4, Direction: Up},
+		{Version: 2, Direction: Up},
+		{Version: 2, Direction: Down},
+		{Version: 5, Direction: Up},
+	}
+	if got := historyBaseline(history); got != 2 {
+		t.Fatalf("expected baseline 2, got: %d", got)
+	}
+	if got := historyBaseline(nil); got != NoMigrationVersion {
+		t.Fatalf("expected no baseline, got: %d", got)
+	}
+}
@@ -0,0 +1,95 @@
+package lightmigrate
+
+import (
+	"context"
+)
+
+// PlannedMigration describes a single migration that would be applied by the migrator.
+type PlannedMigration struct {
+	// Version is the version of the migration.
+	Version uint64
+
+	// Identifier helps finding the migration in the source.
+	Identifier string
+
+	// Direction is either Up or Down.
+	Direction Direction
+
+	// ResultingVersion is the database version after the migration has been applied.
+	ResultingVersion uint64
+}
+
+func (m *migrator) Plan(version uint64) ([]PlannedMigration, error) {
+	// avoid multiple concurrent runs of the migration
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	// get current version and dirty state
+	curVersion, dirty, err := m.driver.GetVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	if dirty {
+		return nil, ErrDatabaseDirty
+	}
+
+	return m.plan(context.Background(), curVersion, version)
+}
+
+// plan collects all migrations that GetMigrations would emit, without applying them.
+func (m *migrator) plan(ctx context.Context, currentVersion, targetVersion uint64) ([]PlannedMigration, error) {
+	// create the shutdown channel
+	m.shutdown = make(chan bool, 1)
+
+	planned := make([]PlannedMigration, 0)
+
+	// migrations that have been merged late are applied first
+	if targetVersion >= currentVersion {
+		outOfOrder, err := m.getOutOfOrderMigrations(currentVersion)
+		if err != nil {
+			return nil, err
+		}
+		for _, migration := range outOfOrder {
+			if migration.Error() != nil {
+				return nil, migration.Error()
+			}
+			_ = migration.Contents.Close()
+			planned = append(planned, newPlannedMigration(migration))
+		}
+	}
+
+	migrations := make(chan *migrationData)
+	err := m.GetMigrations(ctx, currentVersion, targetVersion, migrations)
+	if err == ErrNoChange {
+		return planned, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for migration := range migrations {
+		if migration.Error() != nil {
+			m.shutdown <- true // shutdown migration producer
+			return nil, migration.Error()
+		}
+		_ = migration.Contents.Close()
+
+		planned = append(planned, newPlannedMigration(migration))
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return planned, nil
+}
+
+func newPlannedMigration(migration *migrationData) PlannedMigration {
+	return PlannedMigration{
+		Version:          migration.Version,
+		Identifier:       migration.Identifier,
+		Direction:        migration.Direction,
+		ResultingVersion: migration.TargetVersion,
+	}
+}
@@ -0,0 +1,114 @@
+package lightmigrate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/h44z/lightmigrate/test"
+)
+
+func Test_migrator_Plan_Up(t *testing.T) {
+	m := getTestMigrator()
+	s, _ := test.NewMockSource(1, 3)
+	s.Identifier = "mock"
+	m.source = s
+
+	got, err := m.Plan(3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []PlannedMigration{
+		{Version: 1, Identifier: "mock", Direction: Up, ResultingVersion: 1},
+		{Version: 2, Identifier: "mock", Direction: Up, ResultingVersion: 2},
+		{Version: 3, Identifier: "mock", Direction: Up, ResultingVersion: 3},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected plan: %v, got: %v", want, got)
+	}
+	if v := m.driver.(*test.MockDriver).Version; v != NoMigrationVersion {
+		t.Fatalf("expected database to be unchanged, got version: %d", v)
+	}
+}
+
+func Test_migrator_Plan_Down(t *testing.T) {
+	m := getTestMigrator()
+	s, _ := test.NewMockSource(1, 3)
+	m.source = s
+	m.driver.(*test.MockDriver).Version = 3
+
+	got, err := m.Plan(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []PlannedMigration{
+		{Version: 3, Direction: Down, ResultingVersion: 2},
+		{Version: 2, Direction: Down, ResultingVersion: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected plan: %v, got: %v", want, got)
+	}
+}
+
+func Test_migrator_Plan_NoChange(t *testing.T) {
+	m := getTestMigrator()
+	m.driver.(*test.MockDriver).Version = 2
+
+	got, err := m.Plan(2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("expected empty plan, got: %v", got)
+	}
+}
+
+func Test_migrator_Plan_Dirty(t *testing.T) {
+	m := getTestMigrator()
+	m.driver.(*test.MockDriver).Dirty = true
+
+	_, err := m.Plan(2)
+	if err != ErrDatabaseDirty {
+		t.Fatalf("expected ErrDatabaseDirty error, got: %v", err)
+	}
+}
+
+func Test_migrator_Plan_WrongTarget(t *testing.T) {
+	m := getTestMigrator()
+
+	_, err := m.Plan(5)
+	if err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+}
+
+func Test_migrator_Plan_DriverError(t *testing.T) {
+	m := getTestMigrator()
+	s, _ := test.NewMockSource(1, 3)
+	m.source = s
+	m.driver.(*test.MockDriver).Error = errors.New("drivererror")
+
+	_, err := m.Plan(2)
+	if err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+}
+
+func Test_migrator_Plan_Sparse(t *testing.T) {
+	m := getTestMigrator()
+	m.source = getTestSource(t, "sparse-migrations")
+	m.driver.(*test.MockDriver).Version = 30
+
+	got, err := m.Plan(NoMigrationVersion)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []PlannedMigration{
+		{Version: 30, Identifier: "v30", Direction: Down, ResultingVersion: 20},
+		{Version: 20, Identifier: "v20", Direction: Down, ResultingVersion: 10},
+		{Version: 10, Identifier: "v10", Direction: Down, ResultingVersion: NoMigrationVersion},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected plan: %v, got: %v", want, got)
+	}
+}
@@ -0,0 +1,123 @@
+// Package postgres implements a lightmigrate.MigrationDriver for PostgreSQL databases.
+// It works with any database/sql PostgreSQL driver, like github.com/lib/pq or github.com/jackc/pgx/v4/stdlib.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/h44z/lightmigrate/sqldriver"
+)
+
+// nonTransactionalRegex matches statements that cannot be executed within a transaction block.
+var nonTransactionalRegex = regexp.MustCompile(`(?is)^(VACUUM|CREATE\s+DATABASE|DROP\s+DATABASE|ALTER\s+SYSTEM|` +
+	`CREATE\s+TABLESPACE|DROP\s+TABLESPACE|REINDEX\s.*\sCONCURRENTLY|(CREATE|DROP)\s+(UNIQUE\s+)?INDEX\s+CONCURRENTLY)\b`)
+
+// lockKeys contains the advisory lock keys of all locked connections. The key is stored on Lock, as the
+// current schema may change during a migration.
+var lockKeys sync.Map
+
+// Dialect is the sqldriver.Dialect for PostgreSQL. The migration lock is a session level advisory lock,
+// keyed by a hash of the current database and schema name.
+type Dialect struct {
+	sqldriver.PostgresDialect
+}
+
+// Lock is part of sqldriver.Dialect interface implementation.
+// pg_advisory_lock waits until the lock is available, use a context to limit the wait time.
+func (d Dialect) Lock(ctx context.Context, conn *sql.Conn, _ string) error {
+	key, err := d.lockKey(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if _, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", key); err != nil {
+		return err
+	}
+	lockKeys.Store(conn, key)
+	return nil
+}
+
+// Unlock is part of sqldriver.Dialect interface implementation.
+// The lock is released using the key of the Lock call.
+func (d Dialect) Unlock(ctx context.Context, conn *sql.Conn, _ string) error {
+	key, ok := lockKeys.Load(conn)
+	if !ok {
+		return fmt.Errorf("advisory lock is not held by this connection")
+	}
+
+	var released bool
+	err := conn.QueryRowContext(ctx, "SELECT pg_advisory_unlock($1)", key).Scan(&released)
+	if err != nil {
+		return err
+	}
+	lockKeys.Delete(conn)
+	if !released {
+		return fmt.Errorf("advisory lock %d was not held", key)
+	}
+	return nil
+}
+
+// Transactional is part of sqldriver.TransactionDialect interface implementation.
+// Statements like CREATE INDEX CONCURRENTLY or VACUUM cannot run inside a transaction block.
+// Leading comments and whitespace of the statements are ignored.
+func (d Dialect) Transactional(statements []string) bool {
+	for _, s := range statements {
+		if nonTransactionalRegex.MatchString(trimLeadingComments(s)) {
+			return false
+		}
+	}
+	return true
+}
+
+// trimLeadingComments removes whitespace, line comments and block comments from the start of the statement.
+func trimLeadingComments(statement string) string {
+	for {
+		statement = strings.TrimSpace(statement)
+		switch {
+		case strings.HasPrefix(statement, "--"):
+			end := strings.IndexByte(statement, '\n')
+			if end < 0 {
+				return ""
+			}
+			statement = statement[end+1:]
+		case strings.HasPrefix(statement, "/*"):
+			end := strings.Index(statement, "*/")
+			if end < 0 {
+				return ""
+			}
+			statement = statement[end+2:]
+		default:
+			return statement
+		}
+	}
+}
+
+// lockKey returns the advisory lock key for the current database and schema. The schema is NULL if
+// no schema of the search path exists.
+func (d Dialect) lockKey(ctx context.Context, conn *sql.Conn) (int64, error) {
+	var database string
+	var schema sql.NullString
+	err := conn.QueryRowContext(ctx, "SELECT current_database(), current_schema()").Scan(&database, &schema)
+	if err != nil {
+		return 0, fmt.Errorf("unable to get database and schema name: %w", err)
+	}
+	return sqldriver.LockKey(database, schema.String), nil
+}
+
+// NewDriver returns a new MigrationDriver for a PostgreSQL database. The version and dirty state are
+// stored in the schema_migrations table of the current schema, the migration history in the
+// schema_migrations_history table. Each migration runs in a transaction.
+// Options of the sqldriver package can be used to change the defaults, e.g. sqldriver.WithVersionTable.
+func NewDriver(db *sql.DB, opts ...sqldriver.DriverOption) (*sqldriver.Driver, error) {
+	defaults := []sqldriver.DriverOption{
+		sqldriver.WithDialect(Dialect{}),
+		sqldriver.WithVersionTable(sqldriver.DefaultVersionTable),
+		sqldriver.WithTransactions(true),
+	}
+
+	return sqldriver.NewDriver(db, append(defaults, opts...)...)
+}
@@ -0,0 +1,192 @@
+package postgres
+
+import (
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/h44z/lightmigrate/sqldriver"
+	"github.com/h44z/lightmigrate/test"
+)
+
+func getTestDriver(t *testing.T, opts ...sqldriver.DriverOption) (*sqldriver.Driver, *test.MockSQL) {
+	db, mock := test.NewMockSQL()
+	mock.QueryResults["SELECT current_database()"] = [][]driver.Value{{"app", "public"}}
+	d, err := NewDriver(db, opts...)
+	if err != nil {
+		t.Fatalf("unable to setup driver: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = d.Close()
+		_ = db.Close()
+	})
+	return d, mock
+}
+
+func TestNewDriver(t *testing.T) {
+	_, mock := getTestDriver(t)
+
+	want := []string{`CREATE TABLE IF NOT EXISTS "schema_migrations" (version BIGINT NOT NULL, dirty BOOLEAN NOT NULL)`}
+	if got := mock.GetStatements(); !reflect.DeepEqual(got[:1], want) ||
+		!strings.HasPrefix(got[1], `CREATE TABLE IF NOT EXISTS "schema_migrations_history" (`) {
+		t.Fatalf("unexpected statements: %v", got)
+	}
+}
+
+func TestNewDriver_Options(t *testing.T) {
+	_, mock := getTestDriver(t, sqldriver.WithVersionTable("app.versions"))
+
+	want := []string{`CREATE TABLE IF NOT EXISTS "app"."versions" (version BIGINT NOT NULL, dirty BOOLEAN NOT NULL)`}
+	if got := mock.GetStatements(); !reflect.DeepEqual(got[:1], want) ||
+		!strings.HasPrefix(got[1], `CREATE TABLE IF NOT EXISTS "app"."versions_history" (`) {
+		t.Fatalf("unexpected statements: %v", got)
+	}
+}
+
+func TestDialect_Lock(t *testing.T) {
+	d, mock := getTestDriver(t)
+
+	if err := d.Lock(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := d.Unlock(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{
+		"SELECT current_database(), current_schema()",
+		"SELECT pg_advisory_lock($1)",
+		"SELECT pg_advisory_unlock($1)",
+	}
+	if got := mock.GetStatements()[2:]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected statements: %v", got)
+	}
+	key := sqldriver.LockKey("app", "public")
+	if mock.Args[3][0] != key || mock.Args[4][0] != key {
+		t.Fatalf("unexpected lock keys: %v, %v", mock.Args[3], mock.Args[4])
+	}
+}
+
+func TestDialect_Lock_SchemaChanged(t *testing.T) {
+	d, mock := getTestDriver(t)
+
+	if err := d.Lock(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// a migration created a schema that comes first in the search path
+	mock.QueryResults["SELECT current_database()"] = [][]driver.Value{{"app", "migrator"}}
+	if err := d.Unlock(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if key := sqldriver.LockKey("app", "public"); mock.Args[4][0] != key {
+		t.Fatalf("expected unlock with lock key %d, got: %v", key, mock.Args[4])
+	}
+}
+
+func TestDialect_Unlock_NotHeld(t *testing.T) {
+	d, mock := getTestDriver(t)
+	mock.QueryResults["SELECT pg_advisory_unlock"] = [][]driver.Value{{false}}
+
+	if err := d.Lock(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := d.Unlock(); err == nil || !strings.Contains(err.Error(), "was not held") {
+		t.Fatalf("expected not held error, got: %v", err)
+	}
+}
+
+func TestDialect_Lock_Error(t *testing.T) {
+	d, mock := getTestDriver(t)
+	mock.FailOn = "current_database"
+	mock.Error = errors.New("connection lost")
+
+	if err := d.Lock(); !errors.Is(err, mock.Error) {
+		t.Fatalf("expected lock error, got: %v", err)
+	}
+}
+
+func TestDialect_Lock_NullSchema(t *testing.T) {
+	d, mock := getTestDriver(t)
+	mock.QueryResults["SELECT current_database()"] = [][]driver.Value{{"app", nil}}
+
+	if err := d.Lock(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key := sqldriver.LockKey("app", ""); mock.Args[3][0] != key {
+		t.Fatalf("unexpected lock key: %v", mock.Args[3])
+	}
+}
+
+func TestDriver_RunMigration_Transaction(t *testing.T) {
+	d, mock := getTestDriver(t)
+
+	err := d.RunMigration(strings.NewReader("CREATE TABLE a (id INT);\nINSERT INTO a VALUES (1);"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"BEGIN", "CREATE TABLE a (id INT)", "INSERT INTO a VALUES (1)", "COMMIT"}
+	if got := mock.GetStatements()[2:]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected statements: %v", got)
+	}
+}
+
+func TestDriver_RunMigration_NoTransaction(t *testing.T) {
+	d, mock := getTestDriver(t)
+
+	err := d.RunMigration(strings.NewReader("CREATE INDEX CONCURRENTLY idx ON a (id);"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"CREATE INDEX CONCURRENTLY idx ON a (id)"}
+	if got := mock.GetStatements()[2:]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected statements: %v", got)
+	}
+}
+
+func TestDriver_RunMigration_NoSplitting(t *testing.T) {
+	tests := []string{
+		"-- add index\n\nCREATE INDEX CONCURRENTLY idx ON a (id);",
+		"CREATE TABLE a (id INT);\nCREATE INDEX CONCURRENTLY idx ON a (id);",
+	}
+	for _, migration := range tests {
+		d, mock := getTestDriver(t, sqldriver.WithStatementSplitting(false))
+
+		if err := d.RunMigration(strings.NewReader(migration)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := mock.GetStatements()[2:]; !reflect.DeepEqual(got, []string{migration}) {
+			t.Fatalf("expected migration without transaction, got: %v", got)
+		}
+	}
+}
+
+func TestDialect_Transactional(t *testing.T) {
+	tests := []struct {
+		statement string
+		want      bool
+	}{
+		{"CREATE TABLE a (id INT)", true},
+		{"CREATE INDEX idx ON a (id)", true},
+		{"create unique index concurrently idx on a (id)", false},
+		{"DROP INDEX CONCURRENTLY idx", false},
+		{"REINDEX TABLE CONCURRENTLY a", false},
+		{"VACUUM FULL a", false},
+		{"CREATE DATABASE app", false},
+		{"INSERT INTO a VALUES ('VACUUM')", true},
+		{"-- reindex\n/* online */ REINDEX INDEX CONCURRENTLY idx", false},
+		{"\n\t VACUUM a", false},
+		{"-- VACUUM a", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.statement, func(t *testing.T) {
+			if got := (Dialect{}).Transactional([]string{"SELECT 1", tt.statement}); got != tt.want {
+				t.Errorf("Transactional() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package lightmigrate
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+)
+
+const (
+	// DefaultUpMarker starts the up section of a single file migration.
+	DefaultUpMarker = "-- +migrate Up"
+	// DefaultDownMarker starts the down section of a single file migration.
+	DefaultDownMarker = "-- +migrate Down"
+)
+
+// migrationSections contains the sections of a single file migration.
+type migrationSections map[Direction][]byte
+
+// splitSections splits a single file migration into its up and down sections. A section starts
+// at a line beginning with the section marker and ends at the next marker or the end of the file.
+// Contents before the first marker are ignored, marker lines are not part of the sections.
+func splitSections(r io.Reader, upMarker, downMarker string) (migrationSections, error) {
+	sections := make(migrationSections)
+	buffers := make(map[Direction]*bytes.Buffer)
+	var current *bytes.Buffer
+
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, upMarker):
+			current = sectionBuffer(buffers, Up)
+		case strings.HasPrefix(trimmed, downMarker):
+			current = sectionBuffer(buffers, Down)
+		case current != nil:
+			current.WriteString(line)
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	for direction, buf := range buffers {
+		sections[direction] = buf.Bytes()
+	}
+
+	return sections, nil
+}
+
+func sectionBuffer(buffers map[Direction]*bytes.Buffer, direction Direction) *bytes.Buffer {
+	if _, ok := buffers[direction]; !ok {
+		buffers[direction] = &bytes.Buffer{}
+	}
+	return buffers[direction]
+}
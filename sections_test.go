@@ -0,0 +1,63 @@
+package lightmigrate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_splitSections(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     migrationSections
+	}{
+		{
+			name:     "up and down",
+			contents: "-- comment\n-- +migrate Up\nCREATE TABLE a;\n\n-- +migrate Down\nDROP TABLE a;",
+			want: migrationSections{
+				Up:   []byte("CREATE TABLE a;\n\n"),
+				Down: []byte("DROP TABLE a;"),
+			},
+		},
+		{
+			name:     "down first",
+			contents: "-- +migrate Down\nDROP TABLE a;\n-- +migrate Up\nCREATE TABLE a;\n",
+			want: migrationSections{
+				Up:   []byte("CREATE TABLE a;\n"),
+				Down: []byte("DROP TABLE a;\n"),
+			},
+		},
+		{
+			name:     "indented marker with suffix",
+			contents: "  -- +migrate Up notransaction\nCREATE TABLE a;\n",
+			want: migrationSections{
+				Up: []byte("CREATE TABLE a;\n"),
+			},
+		},
+		{
+			name:     "empty section",
+			contents: "-- +migrate Up\n-- +migrate Down\nDROP TABLE a;\n",
+			want: migrationSections{
+				Up:   []byte(nil),
+				Down: []byte("DROP TABLE a;\n"),
+			},
+		},
+		{
+			name:     "no markers",
+			contents: "CREATE TABLE a;\n",
+			want:     migrationSections{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitSections(strings.NewReader(tt.contents), DefaultUpMarker, DefaultDownMarker)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("splitSections() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
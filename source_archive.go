@@ -0,0 +1,245 @@
+package lightmigrate
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// NewZipSource returns a new MigrationSource that reads migrations from a zip archive.
+// basePath is the directory within the archive that contains the migrations, use "." for the archive root.
+// The options of NewFsSource are supported.
+func NewZipSource(r io.ReaderAt, size int64, basePath string, opts ...FsSourceOption) (MigrationSource, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFsSource(zr, basePath, opts...)
+}
+
+// NewTarSource returns a new MigrationSource that reads migrations from a tar archive.
+// basePath is the directory within the archive that contains the migrations, use "." for the archive root.
+// The options of NewFsSource are supported. All files below the base path are loaded into memory.
+func NewTarSource(r io.Reader, basePath string, opts ...FsSourceOption) (MigrationSource, error) {
+	basePath = path.Clean(basePath)
+	fsys := newTarFS()
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean(strings.TrimPrefix(header.Name, "/"))
+		if !fs.ValidPath(name) || (basePath != "." && !strings.HasPrefix(name, basePath+"/")) {
+			continue
+		}
+
+		contents, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		fsys.add(name, header.FileInfo(), contents)
+	}
+
+	return NewFsSource(fsys, basePath, opts...)
+}
+
+// NewTarGzSource returns a new MigrationSource that reads migrations from a gzip compressed tar archive.
+// basePath is the directory within the archive that contains the migrations, use "." for the archive root.
+// The options of NewFsSource are supported. All files below the base path are loaded into memory.
+func NewTarGzSource(r io.Reader, basePath string, opts ...FsSourceOption) (MigrationSource, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	return NewTarSource(gz, basePath, opts...)
+}
+
+// NewArchiveSource returns a new MigrationSource that reads migrations from an archive file.
+// The archive type is detected by the file extension, supported are .zip, .tar, .tar.gz and .tgz.
+// basePath is the directory within the archive that contains the migrations, use "." for the archive root.
+// The options of NewFsSource are supported.
+func NewArchiveSource(fileName string, basePath string, opts ...FsSourceOption) (MigrationSource, error) {
+	name := strings.ToLower(fileName)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		zr, err := zip.OpenReader(fileName)
+		if err != nil {
+			return nil, err
+		}
+		source, err := NewFsSource(zr, basePath, opts...) // the source closes the zip file
+		if err != nil {
+			_ = zr.Close()
+			return nil, err
+		}
+		return source, nil
+	case strings.HasSuffix(name, ".tar"):
+		file, err := os.Open(fileName)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return NewTarSource(file, basePath, opts...)
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		file, err := os.Open(fileName)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return NewTarGzSource(file, basePath, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported archive type: %s", fileName)
+	}
+}
+
+// tarFS is a read-only in-memory fs.FS of the regular files of a tar archive. Directories are derived
+// from the file paths.
+type tarFS struct {
+	files map[string]*tarFile
+	dirs  map[string]map[string]fs.DirEntry
+}
+
+// tarFile is a regular file of a tar archive.
+type tarFile struct {
+	info     fs.FileInfo
+	contents []byte
+}
+
+func newTarFS() *tarFS {
+	return &tarFS{
+		files: make(map[string]*tarFile),
+		dirs:  map[string]map[string]fs.DirEntry{".": {}},
+	}
+}
+
+// add stores a file and registers it in all parent directories.
+func (t *tarFS) add(name string, info fs.FileInfo, contents []byte) {
+	t.files[name] = &tarFile{info: info, contents: contents}
+
+	entry := fs.FileInfoToDirEntry(info)
+	for name != "." {
+		dir := path.Dir(name)
+		if _, ok := t.dirs[dir]; !ok {
+			t.dirs[dir] = make(map[string]fs.DirEntry)
+		}
+		t.dirs[dir][path.Base(name)] = entry
+
+		name = dir
+		entry = fs.FileInfoToDirEntry(tarDirInfo{name: path.Base(dir)})
+	}
+}
+
+// Open is part of fs.FS interface implementation.
+func (t *tarFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if f, ok := t.files[name]; ok {
+		return &openTarFile{Reader: bytes.NewReader(f.contents), info: f.info}, nil
+	}
+	if _, ok := t.dirs[name]; ok {
+		entries, _ := t.ReadDir(name)
+		return &openTarDir{path: name, entries: entries}, nil
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadDir is part of fs.ReadDirFS interface implementation.
+func (t *tarFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	dir, ok := t.dirs[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	entries := make([]fs.DirEntry, 0, len(dir))
+	for _, e := range dir {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
+
+// openTarFile is an opened file of a tarFS.
+type openTarFile struct {
+	*bytes.Reader
+	info fs.FileInfo
+}
+
+// Stat is part of fs.File interface implementation.
+func (f *openTarFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+// Close is part of fs.File interface implementation.
+func (f *openTarFile) Close() error {
+	return nil
+}
+
+// openTarDir is an opened directory of a tarFS.
+type openTarDir struct {
+	path    string
+	entries []fs.DirEntry
+	offset  int
+}
+
+// Stat is part of fs.File interface implementation.
+func (d *openTarDir) Stat() (fs.FileInfo, error) {
+	return tarDirInfo{name: path.Base(d.path)}, nil
+}
+
+// Read is part of fs.File interface implementation.
+func (d *openTarDir) Read(_ []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.path, Err: fs.ErrInvalid}
+}
+
+// Close is part of fs.File interface implementation.
+func (d *openTarDir) Close() error {
+	return nil
+}
+
+// ReadDir is part of fs.ReadDirFile interface implementation.
+func (d *openTarDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	remaining := d.entries[d.offset:]
+	if n > 0 && len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if n > 0 && n < len(remaining) {
+		remaining = remaining[:n]
+	}
+	d.offset += len(remaining)
+	return remaining, nil
+}
+
+// tarDirInfo describes a directory of a tarFS.
+type tarDirInfo struct {
+	name string
+}
+
+func (d tarDirInfo) Name() string       { return d.name }
+func (d tarDirInfo) Size() int64        { return 0 }
+func (d tarDirInfo) Mode() fs.FileMode  { return fs.ModeDir | 0555 }
+func (d tarDirInfo) ModTime() time.Time { return time.Time{} }
+func (d tarDirInfo) IsDir() bool        { return true }
+func (d tarDirInfo) Sys() interface{}   { return nil }
@@ -0,0 +1,248 @@
+package lightmigrate
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+var testArchiveFiles = map[string]string{
+	"migrations/001_first.up.json":   `{"1": "up"}`,
+	"migrations/001_first.down.json": `{"1": "down"}`,
+	"migrations/002_second.up.json":  `{"2": "up"}`,
+	"migrations/README.md":           "ignored",
+	"migrations/sub/003_sub.up.json": "ignored",
+	"004_root.up.json":               `{"4": "up"}`,
+}
+
+func createTestZip(t *testing.T, files map[string]string) []byte {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for name, body := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("unable to create zip entry: %v", err)
+		}
+		_, _ = w.Write([]byte(body))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("unable to create zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func createTestTar(t *testing.T, files map[string]string) []byte {
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	_ = tw.WriteHeader(&tar.Header{Name: "migrations/", Typeflag: tar.TypeDir, Mode: 0755})
+	for name, body := range files {
+		err := tw.WriteHeader(&tar.Header{Name: "./" + name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(body))})
+		if err != nil {
+			t.Fatalf("unable to create tar entry: %v", err)
+		}
+		_, _ = tw.Write([]byte(body))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("unable to create tar: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func createTestTarGz(t *testing.T) []byte {
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	_, _ = gz.Write(createTestTar(t, testArchiveFiles))
+	if err := gz.Close(); err != nil {
+		t.Fatalf("unable to create tar.gz: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func checkTestArchiveSource(t *testing.T, s MigrationSource) {
+	defer s.Close()
+
+	first, err := s.First()
+	if err != nil || first != 1 {
+		t.Fatalf("expected first version 1, got: %d (%v)", first, err)
+	}
+	next, err := s.Next(1)
+	if err != nil || next != 2 {
+		t.Fatalf("expected next version 2, got: %d (%v)", next, err)
+	}
+	if _, err := s.Next(2); err == nil {
+		t.Fatalf("expected no more migrations")
+	}
+
+	down, identifier, err := s.ReadDown(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer down.Close()
+	if identifier != "first" {
+		t.Fatalf("expected identifier first, got: %s", identifier)
+	}
+	contents, _ := io.ReadAll(down)
+	if string(contents) != `{"1": "down"}` {
+		t.Fatalf("unexpected contents, got: %s", contents)
+	}
+}
+
+func TestNewZipSource(t *testing.T) {
+	data := createTestZip(t, testArchiveFiles)
+	s, err := NewZipSource(bytes.NewReader(data), int64(len(data)), "migrations")
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+	checkTestArchiveSource(t, s)
+}
+
+func TestNewZipSource_Invalid(t *testing.T) {
+	data := []byte("no zip")
+	_, err := NewZipSource(bytes.NewReader(data), int64(len(data)), "migrations")
+	if err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+}
+
+func TestNewTarSource(t *testing.T) {
+	s, err := NewTarSource(bytes.NewReader(createTestTar(t, testArchiveFiles)), "migrations/")
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+	checkTestArchiveSource(t, s)
+}
+
+func TestNewTarSource_Root(t *testing.T) {
+	s, err := NewTarSource(bytes.NewReader(createTestTar(t, testArchiveFiles)), ".")
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+	first, err := s.First()
+	if err != nil || first != 4 {
+		t.Fatalf("expected first version 4, got: %d (%v)", first, err)
+	}
+}
+
+func TestNewTarSource_FS(t *testing.T) {
+	fsys := newTarFS()
+	for name, body := range testArchiveFiles {
+		fsys.add(name, memoryFileInfo{name: path.Base(name), size: int64(len(body))}, []byte(body))
+	}
+	if err := fstest.TestFS(fsys, "004_root.up.json", "migrations/001_first.up.json", "migrations/sub/003_sub.up.json"); err != nil {
+		t.Fatalf("invalid file system: %v", err)
+	}
+}
+
+func TestNewTarSource_Invalid(t *testing.T) {
+	_, err := NewTarSource(bytes.NewReader([]byte("no tar file, but long enough to be read as a header")), ".")
+	if err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+}
+
+func TestNewTarGzSource(t *testing.T) {
+	s, err := NewTarGzSource(bytes.NewReader(createTestTarGz(t)), "migrations")
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+	checkTestArchiveSource(t, s)
+}
+
+func TestNewTarGzSource_Invalid(t *testing.T) {
+	_, err := NewTarGzSource(bytes.NewReader(createTestTar(t, testArchiveFiles)), "migrations")
+	if err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+}
+
+func TestNewArchiveSource(t *testing.T) {
+	dir := t.TempDir()
+	archives := map[string][]byte{
+		"migrations.zip":    createTestZip(t, testArchiveFiles),
+		"migrations.tar":    createTestTar(t, testArchiveFiles),
+		"migrations.tar.gz": createTestTarGz(t),
+		"migrations.TGZ":    createTestTarGz(t),
+	}
+	for name, data := range archives {
+		t.Run(name, func(t *testing.T) {
+			fileName := filepath.Join(dir, name)
+			if err := os.WriteFile(fileName, data, 0600); err != nil {
+				t.Fatalf("unable to write archive: %v", err)
+			}
+
+			s, err := NewArchiveSource(fileName, "migrations")
+			if err != nil {
+				t.Fatalf("unable to setup source: %v", err)
+			}
+			checkTestArchiveSource(t, s)
+		})
+	}
+}
+
+func TestNewArchiveSource_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := NewArchiveSource(filepath.Join(dir, "migrations.rar"), "."); err == nil {
+		t.Fatalf("expected unsupported archive error")
+	}
+	for _, name := range []string{"missing.zip", "missing.tar", "missing.tar.gz"} {
+		if _, err := NewArchiveSource(filepath.Join(dir, name), "."); !os.IsNotExist(err) {
+			t.Fatalf("expected not exist error for %s, got: %v", name, err)
+		}
+	}
+
+	fileName := filepath.Join(dir, "migrations.zip")
+	_ = os.WriteFile(fileName, createTestZip(t, testArchiveFiles), 0600)
+	if _, err := NewArchiveSource(fileName, "no-such-dir"); err == nil {
+		t.Fatalf("expected missing directory error")
+	}
+}
+
+func TestNewArchiveSource_Options(t *testing.T) {
+	gzipped := &bytes.Buffer{}
+	gz := gzip.NewWriter(gzipped)
+	_, _ = gz.Write([]byte(`{"2": "up"}`))
+	_ = gz.Close()
+
+	files := map[string]string{
+		"migrations/001_init.sql":        "-- +migrate Up\nCREATE TABLE a;\n-- +migrate Down\nDROP TABLE a;\n",
+		"migrations/002_data.up.json.gz": gzipped.String(),
+		"migrations/sub/003_sub.up.json": `{"3": "up"}`,
+	}
+	dir := t.TempDir()
+	archives := map[string][]byte{
+		"migrations.zip": createTestZip(t, files),
+		"migrations.tar": createTestTar(t, files),
+	}
+	for name, data := range archives {
+		t.Run(name, func(t *testing.T) {
+			fileName := filepath.Join(dir, name)
+			if err := os.WriteFile(fileName, data, 0600); err != nil {
+				t.Fatalf("unable to write archive: %v", err)
+			}
+
+			s, err := NewArchiveSource(fileName, "migrations", WithSingleFileMigrations(true), WithRecursive(true))
+			if err != nil {
+				t.Fatalf("unable to setup source: %v", err)
+			}
+			defer s.Close()
+
+			if got, err := readTestTemplate(t, s, 1, Down); err != nil || got != "DROP TABLE a;\n" {
+				t.Fatalf("unexpected down section: %q (%v)", got, err)
+			}
+			if got, err := readTestTemplate(t, s, 2, Up); err != nil || got != `{"2": "up"}` {
+				t.Fatalf("unexpected decompressed migration: %q (%v)", got, err)
+			}
+			if got, err := readTestTemplate(t, s, 3, Up); err != nil || got != `{"3": "up"}` {
+				t.Fatalf("unexpected recursive migration: %q (%v)", got, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package lightmigrate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+// MigrationFunc is a migration written in Go. It receives the driver of the migrator,
+// implementations can type assert it to access the underlying database.
+type MigrationFunc func(ctx context.Context, driver MigrationDriver) error
+
+// funcBody is the migration body returned by FuncSource. Instead of passing the body
+// to MigrationDriver.RunMigration, the migrator calls the migration function.
+// Reading the body returns the migration identifier, so the checksum stays stable.
+type funcBody struct {
+	*strings.Reader
+	fn MigrationFunc
+}
+
+// Close is part of io.Closer interface implementation.
+func (f *funcBody) Close() error {
+	return nil
+}
+
+// FuncSource is a MigrationSource for migrations written in Go.
+type FuncSource struct {
+	migrations *migrations
+	funcs      map[uint64]map[Direction]MigrationFunc
+}
+
+// NewFuncSource returns a new, empty FuncSource. Use Register to add migrations.
+func NewFuncSource() *FuncSource {
+	return &FuncSource{
+		migrations: newMigrations(),
+		funcs:      make(map[uint64]map[Direction]MigrationFunc),
+	}
+}
+
+// Register adds a migration to the source. The down function is optional and may be nil.
+func (f *FuncSource) Register(version uint64, name string, up, down func(ctx context.Context, driver MigrationDriver) error) error {
+	if version == NoMigrationVersion {
+		return ErrVersionNotAllowed
+	}
+	if up == nil {
+		return fmt.Errorf("missing up function for migration %d", version)
+	}
+
+	if _, ok := f.funcs[version]; ok {
+		return fmt.Errorf("duplicate migration function: %d_%s", version, name)
+	}
+	f.funcs[version] = make(map[Direction]MigrationFunc)
+
+	f.migrations.Append(&migration{Version: version, Identifier: name, Direction: Up})
+	f.funcs[version][Up] = up
+
+	if down != nil {
+		f.migrations.Append(&migration{Version: version, Identifier: name, Direction: Down})
+		f.funcs[version][Down] = down
+	}
+
+	return nil
+}
+
+// Close is part of source.Driver interface implementation.
+func (f *FuncSource) Close() error {
+	return nil
+}
+
+// First is part of source.Driver interface implementation.
+func (f *FuncSource) First() (version uint64, err error) {
+	if version, ok := f.migrations.First(); ok {
+		return version, nil
+	}
+	return 0, &fs.PathError{
+		Op:   "first",
+		Path: "func",
+		Err:  fs.ErrNotExist,
+	}
+}
+
+// Prev is part of source.Driver interface implementation.
+func (f *FuncSource) Prev(version uint64) (prevVersion uint64, err error) {
+	if version, ok := f.migrations.Prev(version); ok {
+		return version, nil
+	}
+	return 0, &fs.PathError{
+		Op:   "prev for version " + strconv.FormatUint(version, 10),
+		Path: "func",
+		Err:  fs.ErrNotExist,
+	}
+}
+
+// Next is part of source.Driver interface implementation.
+func (f *FuncSource) Next(version uint64) (nextVersion uint64, err error) {
+	if version, ok := f.migrations.Next(version); ok {
+		return version, nil
+	}
+	return 0, &fs.PathError{
+		Op:   "next for version " + strconv.FormatUint(version, 10),
+		Path: "func",
+		Err:  fs.ErrNotExist,
+	}
+}
+
+// ReadUp is part of source.Driver interface implementation.
+func (f *FuncSource) ReadUp(version uint64) (r io.ReadCloser, identifier string, err error) {
+	if m, ok := f.migrations.Up(version); ok {
+		return f.body(m), m.Identifier, nil
+	}
+	return nil, "", &fs.PathError{
+		Op:   "read up for version " + strconv.FormatUint(version, 10),
+		Path: "func",
+		Err:  fs.ErrNotExist,
+	}
+}
+
+// ReadDown is part of source.Driver interface implementation.
+func (f *FuncSource) ReadDown(version uint64) (r io.ReadCloser, identifier string, err error) {
+	if m, ok := f.migrations.Down(version); ok {
+		return f.body(m), m.Identifier, nil
+	}
+	return nil, "", &fs.PathError{
+		Op:   "read down for version " + strconv.FormatUint(version, 10),
+		Path: "func",
+		Err:  fs.ErrNotExist,
+	}
+}
+
+func (f *FuncSource) body(m *migration) *funcBody {
+	return &funcBody{
+		Reader: strings.NewReader(strconv.FormatUint(m.Version, 10) + "_" + m.Identifier + "." + string(m.Direction)),
+		fn:     f.funcs[m.Version][m.Direction],
+	}
+}
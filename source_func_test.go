@@ -0,0 +1,146 @@
+package lightmigrate
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"reflect"
+	"testing"
+
+	"github.com/h44z/lightmigrate/test"
+)
+
+func getTestFuncSource(t *testing.T, calls *[]string) *FuncSource {
+	s := NewFuncSource()
+	fn := func(name string) MigrationFunc {
+		return func(ctx context.Context, driver MigrationDriver) error {
+			*calls = append(*calls, name)
+			return nil
+		}
+	}
+
+	if err := s.Register(2, "second", fn("2up"), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.Register(1, "first", fn("1up"), fn("1down")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return s
+}
+
+func TestFuncSource_Register(t *testing.T) {
+	s := NewFuncSource()
+	noop := func(ctx context.Context, driver MigrationDriver) error { return nil }
+
+	if err := s.Register(1, "first", noop, noop); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.Register(1, "duplicate", noop, noop); err == nil {
+		t.Fatalf("expected duplicate error")
+	}
+	if err := s.Register(NoMigrationVersion, "zero", noop, noop); err != ErrVersionNotAllowed {
+		t.Fatalf("expected ErrVersionNotAllowed, got: %v", err)
+	}
+	if err := s.Register(2, "no-up", nil, noop); err == nil {
+		t.Fatalf("expected missing up function error")
+	}
+}
+
+func TestFuncSource_Navigation(t *testing.T) {
+	s := getTestFuncSource(t, &[]string{})
+
+	first, err := s.First()
+	if err != nil || first != 1 {
+		t.Fatalf("expected first version 1, got: %d (%v)", first, err)
+	}
+	next, err := s.Next(1)
+	if err != nil || next != 2 {
+		t.Fatalf("expected next version 2, got: %d (%v)", next, err)
+	}
+	prev, err := s.Prev(2)
+	if err != nil || prev != 1 {
+		t.Fatalf("expected prev version 1, got: %d (%v)", prev, err)
+	}
+	if _, err := s.Next(2); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got: %v", err)
+	}
+	if _, err := s.Prev(1); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got: %v", err)
+	}
+	if _, err := NewFuncSource().First(); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestFuncSource_Read(t *testing.T) {
+	s := getTestFuncSource(t, &[]string{})
+
+	up, identifier, err := s.ReadUp(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if identifier != "first" {
+		t.Fatalf("expected identifier first, got: %s", identifier)
+	}
+	contents, _ := io.ReadAll(up)
+	if string(contents) != "1_first.up" {
+		t.Fatalf("unexpected contents, got: %s", contents)
+	}
+	_ = up.Close()
+
+	down, _, err := s.ReadDown(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := down.(*funcBody); !ok {
+		t.Fatalf("expected function body, got: %T", down)
+	}
+
+	if _, _, err := s.ReadDown(2); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got: %v", err)
+	}
+	if _, _, err := s.ReadUp(3); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got: %v", err)
+	}
+}
+
+func TestFuncSource_Migrate(t *testing.T) {
+	calls := make([]string, 0)
+	s := getTestFuncSource(t, &calls)
+	d, _ := test.NewMockDriver()
+
+	m, _ := NewMigrator(s, d)
+	if err := m.Migrate(2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	d.Version = 1
+	if err := m.Migrate(NoMigrationVersion); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(calls, []string{"1up", "2up", "1down"}) {
+		t.Fatalf("unexpected migration calls: %v", calls)
+	}
+}
+
+func TestFuncSource_Migrate_Error(t *testing.T) {
+	s := NewFuncSource()
+	wantErr := errors.New("migration failed")
+	_ = s.Register(1, "failing", func(ctx context.Context, driver MigrationDriver) error {
+		return wantErr
+	}, nil)
+	d, _ := test.NewMockDriver()
+
+	m, _ := NewMigrator(s, d)
+	if err := m.Migrate(1); err != wantErr {
+		t.Fatalf("expected migration error, got: %v", err)
+	}
+	if !d.Dirty {
+		t.Fatalf("expected database to be dirty")
+	}
+}
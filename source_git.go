@@ -0,0 +1,203 @@
+package lightmigrate
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+)
+
+type gitSource struct {
+	migrations *migrations
+
+	repository string
+	commit     string
+	path       string
+}
+
+// NewGitSource returns a new MigrationSource that reads migrations from a local git repository at
+// the given revision (commit, tag or branch), without checking it out. basePath is the directory within
+// the repository that contains the migrations, use "." for the repository root.
+// The git command line client must be available in PATH.
+func NewGitSource(repositoryPath, revision, basePath string) (MigrationSource, error) {
+	g := &gitSource{
+		migrations: newMigrations(),
+		repository: repositoryPath,
+		path:       path.Clean(basePath),
+	}
+
+	err := g.init(revision)
+	if err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// init resolves the revision to a commit and builds the migration index from the tree of that commit.
+func (g *gitSource) init(revision string) error {
+	if revision == "" || strings.HasPrefix(revision, "-") {
+		return fmt.Errorf("invalid revision %q", revision)
+	}
+
+	commit, err := g.git("rev-parse", "--verify", "--quiet", revision+"^{commit}")
+	if err != nil {
+		return fmt.Errorf("invalid revision %s: %w", revision, err)
+	}
+	g.commit = strings.TrimSpace(string(commit))
+
+	tree := g.commit
+	if g.path != "." {
+		tree = g.commit + ":" + g.path
+	}
+	entries, err := g.git("ls-tree", "-z", tree)
+	if err != nil {
+		return err
+	}
+
+	// each entry has the format: <mode> SP <type> SP <object> TAB <name> NUL
+	for _, entry := range strings.Split(string(entries), "\x00") {
+		meta, name, ok := cutString(entry, "\t")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(meta)
+		if len(fields) != 3 || fields[1] != "blob" {
+			continue
+		}
+
+		m, err := parseFileName(name)
+		if err != nil {
+			continue
+		}
+		raw := m.Raw
+		m.Raw = fields[2] // read the blob by object name
+		if !g.migrations.Append(m) {
+			return ErrDuplicateMigration{
+				migration: *m,
+				FileInfo:  memoryFileInfo{name: raw},
+			}
+		}
+	}
+
+	return nil
+}
+
+// git runs a git command in the repository and returns its output.
+func (g *gitSource) git(args ...string) ([]byte, error) {
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	cmd := exec.Command("git", append([]string{"-C", g.repository}, args...)...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err := cmd.Run()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s failed: %w (%s)", args[0], err, msg)
+		}
+		return nil, fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// read returns the contents of a blob.
+func (g *gitSource) read(m *migration) (io.ReadCloser, error) {
+	contents, err := g.git("cat-file", "blob", m.Raw)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(contents)), nil
+}
+
+// location returns a description of the source for error messages.
+func (g *gitSource) location() string {
+	return g.repository + "@" + g.commit + ":" + g.path
+}
+
+// Close is part of source.Driver interface implementation.
+func (g *gitSource) Close() error {
+	return nil
+}
+
+// First is part of source.Driver interface implementation.
+func (g *gitSource) First() (version uint64, err error) {
+	if version, ok := g.migrations.First(); ok {
+		return version, nil
+	}
+	return 0, &fs.PathError{
+		Op:   "first",
+		Path: g.location(),
+		Err:  fs.ErrNotExist,
+	}
+}
+
+// Prev is part of source.Driver interface implementation.
+func (g *gitSource) Prev(version uint64) (prevVersion uint64, err error) {
+	if version, ok := g.migrations.Prev(version); ok {
+		return version, nil
+	}
+	return 0, &fs.PathError{
+		Op:   "prev for version " + strconv.FormatUint(version, 10),
+		Path: g.location(),
+		Err:  fs.ErrNotExist,
+	}
+}
+
+// Next is part of source.Driver interface implementation.
+func (g *gitSource) Next(version uint64) (nextVersion uint64, err error) {
+	if version, ok := g.migrations.Next(version); ok {
+		return version, nil
+	}
+	return 0, &fs.PathError{
+		Op:   "next for version " + strconv.FormatUint(version, 10),
+		Path: g.location(),
+		Err:  fs.ErrNotExist,
+	}
+}
+
+// ReadUp is part of source.Driver interface implementation.
+func (g *gitSource) ReadUp(version uint64) (r io.ReadCloser, identifier string, err error) {
+	if m, ok := g.migrations.Up(version); ok {
+		body, err := g.read(m)
+		if err != nil {
+			return nil, "", err
+		}
+		return body, m.Identifier, nil
+	}
+	return nil, "", &fs.PathError{
+		Op:   "read up for version " + strconv.FormatUint(version, 10),
+		Path: g.location(),
+		Err:  fs.ErrNotExist,
+	}
+}
+
+// ReadDown is part of source.Driver interface implementation.
+func (g *gitSource) ReadDown(version uint64) (r io.ReadCloser, identifier string, err error) {
+	if m, ok := g.migrations.Down(version); ok {
+		body, err := g.read(m)
+		if err != nil {
+			return nil, "", err
+		}
+		return body, m.Identifier, nil
+	}
+	return nil, "", &fs.PathError{
+		Op:   "read down for version " + strconv.FormatUint(version, 10),
+		Path: g.location(),
+		Err:  fs.ErrNotExist,
+	}
+}
+
+// cutString slices s around the first instance of sep (strings.Cut is not available in Go 1.17).
+func cutString(s, sep string) (before, after string, found bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
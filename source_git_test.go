@@ -0,0 +1,202 @@
+package lightmigrate
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/h44z/lightmigrate/test"
+)
+
+// createTestGitRepository creates a repository with two commits, the first one is tagged with v1.
+func createTestGitRepository(t *testing.T) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+
+	dir := t.TempDir()
+	run := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com",
+			"-c", "commit.gpgsign=false", "-c", "tag.gpgsign=false"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v (%s)", args, err, out)
+		}
+	}
+	write := func(name, contents string) {
+		fileName := filepath.Join(dir, name)
+		_ = os.MkdirAll(filepath.Dir(fileName), 0755)
+		if err := os.WriteFile(fileName, []byte(contents), 0644); err != nil {
+			t.Fatalf("unable to write file: %v", err)
+		}
+	}
+
+	run("init", "-q")
+	write("migrations/001_first.up.json", `{"1": "up"}`)
+	write("migrations/001_first.down.json", `{"1": "down"}`)
+	write("migrations/README.md", "ignored")
+	write("migrations/sub/003_sub.up.json", "ignored")
+	write("002_root.up.json", `{"2": "up"}`)
+	run("add", ".")
+	run("commit", "-q", "-m", "first")
+	run("tag", "v1")
+
+	write("migrations/002_second.up.json", `{"2": "up"}`)
+	write("migrations/001_first.down.json", `{"1": "changed"}`)
+	run("add", ".")
+	run("commit", "-q", "-m", "second")
+
+	// uncommitted changes must be ignored
+	write("migrations/003_uncommitted.up.json", `{"3": "up"}`)
+
+	return dir
+}
+
+func TestNewGitSource(t *testing.T) {
+	repo := createTestGitRepository(t)
+
+	s, err := NewGitSource(repo, "HEAD", "migrations")
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+	defer s.Close()
+
+	first, err := s.First()
+	if err != nil || first != 1 {
+		t.Fatalf("expected first version 1, got: %d (%v)", first, err)
+	}
+	next, err := s.Next(1)
+	if err != nil || next != 2 {
+		t.Fatalf("expected next version 2, got: %d (%v)", next, err)
+	}
+	prev, err := s.Prev(2)
+	if err != nil || prev != 1 {
+		t.Fatalf("expected prev version 1, got: %d (%v)", prev, err)
+	}
+	if _, err := s.Next(2); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got: %v", err)
+	}
+	if _, err := s.Prev(1); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got: %v", err)
+	}
+}
+
+func TestNewGitSource_Tag(t *testing.T) {
+	repo := createTestGitRepository(t)
+
+	s, err := NewGitSource(repo, "v1", "migrations/")
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+
+	if _, err := s.Next(1); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected a single migration at tag v1, got: %v", err)
+	}
+
+	down, identifier, err := s.ReadDown(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if identifier != "first" {
+		t.Fatalf("expected identifier first, got: %s", identifier)
+	}
+	contents, _ := io.ReadAll(down)
+	if string(contents) != `{"1": "down"}` {
+		t.Fatalf("unexpected contents, got: %s", contents)
+	}
+}
+
+func TestNewGitSource_Root(t *testing.T) {
+	repo := createTestGitRepository(t)
+
+	s, err := NewGitSource(repo, "HEAD~1", ".")
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+	if first, err := s.First(); err != nil || first != 2 {
+		t.Fatalf("expected first version 2, got: %d (%v)", first, err)
+	}
+}
+
+func TestNewGitSource_Errors(t *testing.T) {
+	repo := createTestGitRepository(t)
+
+	if _, err := NewGitSource(repo, "no-such-revision", "migrations"); err == nil {
+		t.Fatalf("expected invalid revision error")
+	}
+	if _, err := NewGitSource(repo, "--all", "migrations"); err == nil {
+		t.Fatalf("expected invalid revision error")
+	}
+	if _, err := NewGitSource(repo, "HEAD", "no-such-dir"); err == nil {
+		t.Fatalf("expected missing directory error")
+	}
+	if _, err := NewGitSource(t.TempDir(), "HEAD", "."); err == nil {
+		t.Fatalf("expected missing repository error")
+	}
+}
+
+func Test_gitSource_Read(t *testing.T) {
+	repo := createTestGitRepository(t)
+
+	s, err := NewGitSource(repo, "HEAD", "migrations")
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+
+	up, identifier, err := s.ReadUp(2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if identifier != "second" {
+		t.Fatalf("expected identifier second, got: %s", identifier)
+	}
+	contents, _ := io.ReadAll(up)
+	if string(contents) != `{"2": "up"}` {
+		t.Fatalf("unexpected contents, got: %s", contents)
+	}
+
+	down, _, _ := s.ReadDown(1)
+	contents, _ = io.ReadAll(down)
+	if string(contents) != `{"1": "changed"}` {
+		t.Fatalf("unexpected contents, got: %s", contents)
+	}
+
+	if _, _, err := s.ReadUp(3); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got: %v", err)
+	}
+	if _, _, err := s.ReadDown(2); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got: %v", err)
+	}
+}
+
+func Test_gitSource_Migrate(t *testing.T) {
+	repo := createTestGitRepository(t)
+
+	s, err := NewGitSource(repo, "HEAD", "migrations")
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+	d, _ := test.NewMockDriver()
+
+	m, _ := NewMigrator(s, d)
+	if err := m.Migrate(2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.Version != 2 {
+		t.Fatalf("expected version 2, got: %d", d.Version)
+	}
+}
+
+func Test_cutString(t *testing.T) {
+	before, after, found := cutString("a\tb\tc", "\t")
+	if before != "a" || after != "b\tc" || !found {
+		t.Fatalf("unexpected result: %s, %s, %t", before, after, found)
+	}
+	before, after, found = cutString("abc", "\t")
+	if before != "abc" || after != "" || found {
+		t.Fatalf("unexpected result: %s, %s, %t", before, after, found)
+	}
+}
@@ -0,0 +1,225 @@
+package lightmigrate
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type httpSource struct {
+	migrations *migrations
+
+	client   *http.Client
+	baseURL  string
+	manifest string
+
+	// cache stores downloaded migration bodies by file name
+	cacheLock sync.Mutex
+	cache     map[string]httpCacheEntry
+}
+
+type httpCacheEntry struct {
+	etag string
+	body []byte
+}
+
+// HTTPSourceOption is a function that can be used within the HTTP source constructor to
+// modify the source object.
+type HTTPSourceOption func(s *httpSource)
+
+// WithHTTPClient sets the HTTP client used to download the manifest and the migrations.
+func WithHTTPClient(client *http.Client) HTTPSourceOption {
+	return func(s *httpSource) {
+		s.client = client
+	}
+}
+
+// WithManifestName sets the file name of the manifest relative to the base URL. Default: index.txt
+func WithManifestName(name string) HTTPSourceOption {
+	return func(s *httpSource) {
+		s.manifest = name
+	}
+}
+
+// NewHTTPSource returns a new MigrationSource that downloads migrations from a HTTP(S) server.
+// The manifest file in baseURL lists all migration file names, one per line. Empty lines and lines
+// starting with # are ignored. Migration bodies are downloaded lazily and cached using ETags.
+func NewHTTPSource(baseURL string, opts ...HTTPSourceOption) (MigrationSource, error) {
+	s := &httpSource{
+		migrations: newMigrations(),
+		client:     http.DefaultClient,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		manifest:   "index.txt",
+		cache:      make(map[string]httpCacheEntry),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	err := s.init()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// init downloads the manifest and builds the migration index.
+func (s *httpSource) init() error {
+	manifest, err := s.download(s.manifest)
+	if err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(manifest))
+	for scanner.Scan() {
+		name := strings.TrimSpace(scanner.Text())
+		if name == "" || strings.HasPrefix(name, "#") {
+			continue
+		}
+
+		m, err := parseFileName(name)
+		if err != nil {
+			continue
+		}
+		if !s.migrations.Append(m) {
+			return ErrDuplicateMigration{
+				migration: *m,
+				FileInfo:  memoryFileInfo{name: name},
+			}
+		}
+	}
+
+	return scanner.Err()
+}
+
+// download fetches a file relative to the base URL. Responses are cached if the server sends an ETag.
+func (s *httpSource) download(name string) ([]byte, error) {
+	fileURL := s.baseURL + "/" + url.PathEscape(name)
+
+	req, err := http.NewRequest(http.MethodGet, fileURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cacheLock.Lock()
+	cached, isCached := s.cache[name]
+	s.cacheLock.Unlock()
+	if isCached {
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && isCached:
+		return cached.body, nil
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, &fs.PathError{
+			Op:   "download",
+			Path: fileURL,
+			Err:  fs.ErrNotExist,
+		}
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("failed to download %s: %s", fileURL, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		s.cacheLock.Lock()
+		s.cache[name] = httpCacheEntry{etag: etag, body: body}
+		s.cacheLock.Unlock()
+	}
+
+	return body, nil
+}
+
+// Close is part of source.Driver interface implementation.
+func (s *httpSource) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+// First is part of source.Driver interface implementation.
+func (s *httpSource) First() (version uint64, err error) {
+	if version, ok := s.migrations.First(); ok {
+		return version, nil
+	}
+	return 0, &fs.PathError{
+		Op:   "first",
+		Path: s.baseURL,
+		Err:  fs.ErrNotExist,
+	}
+}
+
+// Prev is part of source.Driver interface implementation.
+func (s *httpSource) Prev(version uint64) (prevVersion uint64, err error) {
+	if version, ok := s.migrations.Prev(version); ok {
+		return version, nil
+	}
+	return 0, &fs.PathError{
+		Op:   "prev for version " + strconv.FormatUint(version, 10),
+		Path: s.baseURL,
+		Err:  fs.ErrNotExist,
+	}
+}
+
+// Next is part of source.Driver interface implementation.
+func (s *httpSource) Next(version uint64) (nextVersion uint64, err error) {
+	if version, ok := s.migrations.Next(version); ok {
+		return version, nil
+	}
+	return 0, &fs.PathError{
+		Op:   "next for version " + strconv.FormatUint(version, 10),
+		Path: s.baseURL,
+		Err:  fs.ErrNotExist,
+	}
+}
+
+// ReadUp is part of source.Driver interface implementation.
+func (s *httpSource) ReadUp(version uint64) (r io.ReadCloser, identifier string, err error) {
+	if m, ok := s.migrations.Up(version); ok {
+		body, err := s.download(m.Raw)
+		if err != nil {
+			return nil, "", err
+		}
+		return io.NopCloser(bytes.NewReader(body)), m.Identifier, nil
+	}
+	return nil, "", &fs.PathError{
+		Op:   "read up for version " + strconv.FormatUint(version, 10),
+		Path: s.baseURL,
+		Err:  fs.ErrNotExist,
+	}
+}
+
+// ReadDown is part of source.Driver interface implementation.
+func (s *httpSource) ReadDown(version uint64) (r io.ReadCloser, identifier string, err error) {
+	if m, ok := s.migrations.Down(version); ok {
+		body, err := s.download(m.Raw)
+		if err != nil {
+			return nil, "", err
+		}
+		return io.NopCloser(bytes.NewReader(body)), m.Identifier, nil
+	}
+	return nil, "", &fs.PathError{
+		Op:   "read down for version " + strconv.FormatUint(version, 10),
+		Path: s.baseURL,
+		Err:  fs.ErrNotExist,
+	}
+}
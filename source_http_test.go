@@ -0,0 +1,218 @@
+package lightmigrate
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/h44z/lightmigrate/test"
+)
+
+// testHTTPServer serves migration files and counts the full downloads per file.
+type testHTTPServer struct {
+	*httptest.Server
+	lock      sync.Mutex
+	files     map[string]string
+	downloads map[string]int
+}
+
+func newTestHTTPServer(files map[string]string) *testHTTPServer {
+	s := &testHTTPServer{
+		files:     files,
+		downloads: make(map[string]int),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		name := strings.TrimPrefix(r.URL.Path, "/migrations/")
+		body, ok := s.files[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		etag := `"` + name + "-" + checksumOf(body) + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		s.downloads[name]++
+		w.Header().Set("ETag", etag)
+		_, _ = w.Write([]byte(body))
+	}))
+	return s
+}
+
+func checksumOf(body string) string {
+	checksum, _ := newChecksumReader(strings.NewReader(body)).Checksum()
+	return checksum
+}
+
+func getTestHTTPServer() *testHTTPServer {
+	return newTestHTTPServer(map[string]string{
+		"index.txt":              "# migrations\n001_first.up.json\n001_first.down.json\n\n002_with space.up.json\n004_missing.up.json\nREADME.md\n",
+		"001_first.up.json":      `{"1": "up"}`,
+		"001_first.down.json":    `{"1": "down"}`,
+		"002_with space.up.json": `{"2": "up"}`,
+	})
+}
+
+func TestNewHTTPSource(t *testing.T) {
+	srv := getTestHTTPServer()
+	defer srv.Close()
+
+	s, err := NewHTTPSource(srv.URL+"/migrations/", WithHTTPClient(srv.Client()))
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+	defer s.Close()
+
+	first, err := s.First()
+	if err != nil || first != 1 {
+		t.Fatalf("expected first version 1, got: %d (%v)", first, err)
+	}
+	next, err := s.Next(1)
+	if err != nil || next != 2 {
+		t.Fatalf("expected next version 2, got: %d (%v)", next, err)
+	}
+	prev, err := s.Prev(2)
+	if err != nil || prev != 1 {
+		t.Fatalf("expected prev version 1, got: %d (%v)", prev, err)
+	}
+	if _, err := s.Prev(1); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got: %v", err)
+	}
+	if _, err := s.Next(4); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got: %v", err)
+	}
+}
+
+func TestNewHTTPSource_ManifestName(t *testing.T) {
+	srv := newTestHTTPServer(map[string]string{
+		"manifest": "001_first.up.json\n",
+	})
+	defer srv.Close()
+
+	s, err := NewHTTPSource(srv.URL+"/migrations", WithManifestName("manifest"))
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+	if first, err := s.First(); err != nil || first != 1 {
+		t.Fatalf("expected first version 1, got: %d (%v)", first, err)
+	}
+}
+
+func TestNewHTTPSource_Errors(t *testing.T) {
+	srv := newTestHTTPServer(map[string]string{
+		"index.txt": "001_first.up.json\n001_duplicate.up.json\n",
+	})
+	defer srv.Close()
+
+	_, err := NewHTTPSource(srv.URL + "/migrations")
+	var dup ErrDuplicateMigration
+	if !errors.As(err, &dup) {
+		t.Fatalf("expected ErrDuplicateMigration, got: %v", err)
+	}
+
+	_, err = NewHTTPSource(srv.URL+"/migrations", WithManifestName("missing.txt"))
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got: %v", err)
+	}
+
+	_, err = NewHTTPSource("http://[::1]:namedport")
+	if err == nil {
+		t.Fatalf("expected invalid url error")
+	}
+}
+
+func TestNewHTTPSource_ServerError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	_, err := NewHTTPSource(srv.URL)
+	if err == nil || errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected server error, got: %v", err)
+	}
+}
+
+func Test_httpSource_Read(t *testing.T) {
+	srv := getTestHTTPServer()
+	defer srv.Close()
+
+	s, err := NewHTTPSource(srv.URL + "/migrations")
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		up, identifier, err := s.ReadUp(2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if identifier != "with space" {
+			t.Fatalf("expected identifier with space, got: %s", identifier)
+		}
+		contents, _ := io.ReadAll(up)
+		if string(contents) != `{"2": "up"}` {
+			t.Fatalf("unexpected contents, got: %s", contents)
+		}
+	}
+	if downloads := srv.downloads["002_with space.up.json"]; downloads != 1 {
+		t.Fatalf("expected a single download, got: %d", downloads)
+	}
+
+	down, _, err := s.ReadDown(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	contents, _ := io.ReadAll(down)
+	if string(contents) != `{"1": "down"}` {
+		t.Fatalf("unexpected contents, got: %s", contents)
+	}
+
+	// changed contents are downloaded again
+	srv.lock.Lock()
+	srv.files["001_first.down.json"] = `{"1": "changed"}`
+	srv.lock.Unlock()
+	down, _, _ = s.ReadDown(1)
+	contents, _ = io.ReadAll(down)
+	if string(contents) != `{"1": "changed"}` {
+		t.Fatalf("unexpected contents, got: %s", contents)
+	}
+
+	if _, _, err := s.ReadUp(4); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist for missing file, got: %v", err)
+	}
+	if _, _, err := s.ReadUp(3); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got: %v", err)
+	}
+	if _, _, err := s.ReadDown(2); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got: %v", err)
+	}
+}
+
+func Test_httpSource_Migrate(t *testing.T) {
+	srv := getTestHTTPServer()
+	defer srv.Close()
+
+	s, err := NewHTTPSource(srv.URL + "/migrations")
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+	d, _ := test.NewMockDriver()
+
+	m, _ := NewMigrator(s, d)
+	if err := m.Migrate(2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.Version != 2 {
+		t.Fatalf("expected version 2, got: %d", d.Version)
+	}
+}
@@ -1,11 +1,15 @@
 package lightmigrate
 
 import (
+	"bytes"
+	"compress/gzip"
 	"errors"
+	"fmt"
 	"io"
 	"io/fs"
 	"path"
 	"strconv"
+	"strings"
 )
 
 type fsSource struct {
@@ -13,14 +17,103 @@ type fsSource struct {
 
 	fsys fs.FS
 	path string
+
+	// decompressors stores a decompressor for each file extension
+	decompressors map[string]Decompressor
+
+	// single file migrations with up and down sections
+	singleFile bool
+	upMarker   string
+	downMarker string
+
+	// parser is an optional custom file name parser
+	parser FileNameParser
+
+	// directory layouts
+	recursive   bool
+	versionDirs bool
+}
+
+// FsSourceOption is a function that can be used within the fs source constructor to
+// modify the source object.
+type FsSourceOption func(f *fsSource)
+
+// Decompressor returns a decompressing reader for a compressed migration body.
+type Decompressor func(r io.Reader) (io.ReadCloser, error)
+
+// WithDecompressor registers a decompressor for migration files with the given extension, for example ".zst".
+// A decompressor for ".gz" files is registered by default.
+func WithDecompressor(extension string, decompressor Decompressor) FsSourceOption {
+	return func(f *fsSource) {
+		f.decompressors[extension] = decompressor
+	}
+}
+
+// WithSingleFileMigrations enables support for files that contain the up and down migration, like
+// 001_init.sql. Sections start with the DefaultUpMarker and DefaultDownMarker lines, unless
+// configured differently using WithSectionMarkers.
+func WithSingleFileMigrations(enabled bool) FsSourceOption {
+	return func(f *fsSource) {
+		f.singleFile = enabled
+	}
+}
+
+// WithSectionMarkers enables support for single file migrations with custom section markers,
+// for example "-- +goose Up" and "-- +goose Down".
+func WithSectionMarkers(upMarker, downMarker string) FsSourceOption {
+	return func(f *fsSource) {
+		f.singleFile = true
+		f.upMarker = upMarker
+		f.downMarker = downMarker
+	}
+}
+
+// WithFileNameParser sets a custom file name parser, for example FlywayFileNameParser.
+// If the parser returns an empty direction, the file is treated as single file migration with
+// up and down sections, see WithSectionMarkers. Such files must contain at least one section,
+// otherwise NewFsSource fails with ErrNoSections.
+func WithFileNameParser(parser FileNameParser) FsSourceOption {
+	return func(f *fsSource) {
+		f.parser = parser
+	}
+}
+
+// WithRecursive enables reading migrations from all subdirectories of the base path.
+// Versions must be unique across all directories.
+func WithRecursive(enabled bool) FsSourceOption {
+	return func(f *fsSource) {
+		f.recursive = enabled
+	}
+}
+
+// WithVersionDirectories enables directories per version, for example 0005_add_users/up.sql and
+// 0005_add_users/down.sql. Directory names must match the VersionDirectoryRegex pattern, other files
+// in the version directories (e.g. metadata) are ignored. Matching directories without up or down files,
+// like 2022_q1, are treated as regular directories.
+func WithVersionDirectories(enabled bool) FsSourceOption {
+	return func(f *fsSource) {
+		f.versionDirs = enabled
+	}
 }
 
 // NewFsSource returns a new MigrationSource from io/fs#FS and a relative path.
-func NewFsSource(fsys fs.FS, basePath string) (MigrationSource, error) {
+// Compressed migration files (e.g. 001_name.up.json.gz) are decompressed transparently.
+func NewFsSource(fsys fs.FS, basePath string, opts ...FsSourceOption) (MigrationSource, error) {
 	f := &fsSource{
 		migrations: newMigrations(),
 		fsys:       fsys,
 		path:       basePath,
+		decompressors: map[string]Decompressor{
+			".gz": func(r io.Reader) (io.ReadCloser, error) {
+				return gzip.NewReader(r)
+			},
+		},
+		upMarker:   DefaultUpMarker,
+		downMarker: DefaultDownMarker,
+	}
+
+	for _, opt := range opts {
+		opt(f)
 	}
 
 	err := f.init()
@@ -34,34 +127,184 @@ func NewFsSource(fsys fs.FS, basePath string) (MigrationSource, error) {
 // init prepares not initialized IoFS instance to read migrations from an
 // io/fs#FS instance and a relative path.
 func (f *fsSource) init() error {
-	entries, err := fs.ReadDir(f.fsys, f.path)
-	if err != nil {
-		return err
+	return fs.WalkDir(f.fsys, f.path, func(p string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == f.path {
+			return nil // the base directory itself
+		}
+
+		name := f.relativePath(p)
+		if e.IsDir() {
+			if f.versionDirs && VersionDirectoryRegex.MatchString(e.Name()) {
+				found, err := f.initVersionDirectory(name, e)
+				if err != nil {
+					return err
+				}
+				if found {
+					return fs.SkipDir
+				}
+			}
+			if !f.recursive {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		migrations, err := f.parse(name)
+		if err != nil {
+			return err
+		}
+		for _, m := range migrations {
+			err = f.append(m, e)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// initVersionDirectory adds the up and down migration files of a version directory.
+// All other files in the directory are ignored. If the directory contains no up or down
+// files, it is no version directory (e.g. a grouping directory like 2022_q1) and found is false.
+func (f *fsSource) initVersionDirectory(name string, dir fs.DirEntry) (found bool, err error) {
+	m := VersionDirectoryRegex.FindStringSubmatch(dir.Name())
+	version, err := strconv.ParseUint(m[1], 10, 64)
+	if err != nil || version == NoMigrationVersion {
+		return false, nil // no valid version directory
 	}
 
+	entries, err := fs.ReadDir(f.fsys, path.Join(f.path, name))
+	if err != nil {
+		return false, err
+	}
 	for _, e := range entries {
 		if e.IsDir() {
 			continue
 		}
-		m, err := parseFileName(e.Name())
-		if err != nil {
+		direction := Direction(strings.SplitN(e.Name(), ".", 2)[0])
+		if direction != Up && direction != Down {
 			continue
 		}
-		file, err := e.Info()
+		err = f.append(&migration{
+			Version:    version,
+			Identifier: m[2],
+			Direction:  direction,
+			Raw:        path.Join(name, e.Name()),
+		}, e)
 		if err != nil {
-			return err
-		}
-		if !f.migrations.Append(m) {
-			return ErrDuplicateMigration{
-				migration: *m,
-				FileInfo:  file,
-			}
+			return false, err
 		}
+		found = true
 	}
 
+	return found, nil
+}
+
+// append adds a migration to the index, duplicate migrations result in ErrDuplicateMigration.
+func (f *fsSource) append(m *migration, e fs.DirEntry) error {
+	file, err := e.Info()
+	if err != nil {
+		return err
+	}
+	if !f.migrations.Append(m) {
+		return ErrDuplicateMigration{
+			migration: *m,
+			FileInfo:  file,
+		}
+	}
 	return nil
 }
 
+// relativePath returns the path relative to the base path.
+func (f *fsSource) relativePath(p string) string {
+	if f.path == "." {
+		return p
+	}
+	return strings.TrimPrefix(p, f.path+"/")
+}
+
+// parse returns all migrations contained in a file. The name is relative to the base path, only
+// the base name is parsed. Files that contain no migrations are ignored.
+func (f *fsSource) parse(name string) ([]*migration, error) {
+	if f.parser != nil {
+		return f.parseCustom(name)
+	}
+
+	if m, err := parseFileName(path.Base(name)); err == nil {
+		m.Raw = name
+		return []*migration{m}, nil
+	}
+	if !f.singleFile {
+		return nil, nil
+	}
+
+	m, err := parseSingleFileName(path.Base(name))
+	if err != nil {
+		return nil, nil
+	}
+	m.Raw = name
+
+	return f.parseSections(m)
+}
+
+// parseCustom parses the file name using the custom file name parser.
+func (f *fsSource) parseCustom(name string) ([]*migration, error) {
+	version, identifier, direction, err := f.parser(path.Base(name))
+	if err != nil || version == NoMigrationVersion {
+		return nil, nil
+	}
+
+	m := &migration{
+		Version:    version,
+		Identifier: identifier,
+		Direction:  direction,
+		Raw:        name,
+	}
+	if direction != "" {
+		return []*migration{m}, nil
+	}
+
+	m.Section = true
+	migrations, err := f.parseSections(m)
+	if err == nil && len(migrations) == 0 {
+		err = fmt.Errorf("%s: %w, expected section markers %q and %q", name, ErrNoSections, f.upMarker, f.downMarker)
+	}
+	return migrations, err
+}
+
+// parseSections returns a migration for each section of a single file migration.
+func (f *fsSource) parseSections(m *migration) ([]*migration, error) {
+	// check which sections exist in the file
+	sections, err := f.readSections(m)
+	if err != nil {
+		return nil, err
+	}
+	migrations := make([]*migration, 0, 2)
+	for _, direction := range []Direction{Up, Down} {
+		if _, ok := sections[direction]; ok {
+			section := *m
+			section.Direction = direction
+			migrations = append(migrations, &section)
+		}
+	}
+
+	return migrations, nil
+}
+
+// readSections reads a single file migration and splits it into sections.
+func (f *fsSource) readSections(m *migration) (migrationSections, error) {
+	file, err := f.decompress(m)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return splitSections(file, f.upMarker, f.downMarker)
+}
+
 // open a given file path in the filesystem.
 func (f *fsSource) open(path string) (fs.File, error) {
 	file, err := f.fsys.Open(path)
@@ -80,6 +323,55 @@ func (f *fsSource) open(path string) (fs.File, error) {
 	return nil, err
 }
 
+// read returns the body of a migration.
+func (f *fsSource) read(m *migration) (io.ReadCloser, error) {
+	if !m.Section {
+		return f.decompress(m)
+	}
+
+	sections, err := f.readSections(m)
+	if err != nil {
+		return nil, err
+	}
+	section, ok := sections[m.Direction]
+	if !ok {
+		return nil, &fs.PathError{
+			Op:   "read " + string(m.Direction) + " section",
+			Path: path.Join(f.path, m.Raw),
+			Err:  fs.ErrNotExist,
+		}
+	}
+	return io.NopCloser(bytes.NewReader(section)), nil
+}
+
+// decompress opens a migration file and decompresses it if necessary.
+func (f *fsSource) decompress(m *migration) (io.ReadCloser, error) {
+	file, err := f.open(path.Join(f.path, m.Raw))
+	if err != nil {
+		return nil, err
+	}
+
+	// use the decompressor with the longest matching extension
+	var decompressor Decompressor
+	matched := ""
+	for extension, d := range f.decompressors {
+		if strings.HasSuffix(m.Raw, extension) && len(extension) > len(matched) {
+			decompressor = d
+			matched = extension
+		}
+	}
+	if decompressor == nil {
+		return file, nil
+	}
+
+	r, err := decompressor(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return &decompressedFile{ReadCloser: r, file: file}, nil
+}
+
 // Close is part of source.Driver interface implementation.
 // Closes the file system if possible.
 func (f *fsSource) Close() error {
@@ -129,7 +421,7 @@ func (f *fsSource) Next(version uint64) (nextVersion uint64, err error) {
 // ReadUp is part of source.Driver interface implementation.
 func (f *fsSource) ReadUp(version uint64) (r io.ReadCloser, identifier string, err error) {
 	if m, ok := f.migrations.Up(version); ok {
-		body, err := f.open(path.Join(f.path, m.Raw))
+		body, err := f.read(m)
 		if err != nil {
 			return nil, "", err
 		}
@@ -145,7 +437,7 @@ func (f *fsSource) ReadUp(version uint64) (r io.ReadCloser, identifier string, e
 // ReadDown is part of source.Driver interface implementation.
 func (f *fsSource) ReadDown(version uint64) (r io.ReadCloser, identifier string, err error) {
 	if m, ok := f.migrations.Down(version); ok {
-		body, err := f.open(path.Join(f.path, m.Raw))
+		body, err := f.read(m)
 		if err != nil {
 			return nil, "", err
 		}
@@ -157,3 +449,18 @@ func (f *fsSource) ReadDown(version uint64) (r io.ReadCloser, identifier string,
 		Err:  fs.ErrNotExist,
 	}
 }
+
+// decompressedFile closes the decompressor and the underlying file.
+type decompressedFile struct {
+	io.ReadCloser
+	file io.Closer
+}
+
+// Close is part of io.Closer interface implementation.
+func (d *decompressedFile) Close() error {
+	err := d.ReadCloser.Close()
+	if fileErr := d.file.Close(); err == nil {
+		err = fileErr
+	}
+	return err
+}
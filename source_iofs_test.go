@@ -2,12 +2,17 @@ package lightmigrate
 
 import (
 	"bytes"
+	"encoding/base64"
 	"errors"
+	"io"
 	"io/fs"
 	"io/ioutil"
 	"os"
 	"path"
+	"reflect"
+	"strings"
 	"testing"
+	"testing/fstest"
 )
 
 type closeableFs struct{}
@@ -297,3 +302,363 @@ func Test_fsSource_init_DuplicateMigrations(t *testing.T) {
 		t.Fatalf("expected ErrDuplicateMigration, got: %v", err)
 	}
 }
+
+func Test_fsSource_ReadUp_Compressed(t *testing.T) {
+	s := getTestSource(t, "compressed-migrations")
+
+	up, identifier, err := s.ReadUp(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if identifier != "seed" {
+		t.Fatalf("expected identifier to be seed, got: %s", identifier)
+	}
+	contents, _ := ioutil.ReadAll(up)
+	if bytes.Compare(contents, []byte("{\"1\": \"up\"}")) != 0 {
+		t.Fatalf("unexpected contents, got: %s", contents)
+	}
+	if err := up.Close(); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+
+	plain, _, err := s.ReadUp(2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer plain.Close()
+	contents, _ = ioutil.ReadAll(plain)
+	if bytes.Compare(contents, []byte("{\"2\": \"up\"}")) != 0 {
+		t.Fatalf("unexpected contents, got: %s", contents)
+	}
+}
+
+func Test_fsSource_ReadDown_Compressed(t *testing.T) {
+	s := getTestSource(t, "compressed-migrations")
+
+	down, _, err := s.ReadDown(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer down.Close()
+	contents, _ := ioutil.ReadAll(down)
+	if bytes.Compare(contents, []byte("{\"1\": \"down\"}")) != 0 {
+		t.Fatalf("unexpected contents, got: %s", contents)
+	}
+}
+
+func Test_fsSource_ReadUp_CompressedInvalid(t *testing.T) {
+	s := getTestSource(t, "compressed-migrations")
+
+	_, _, err := s.ReadUp(4)
+	if err == nil {
+		t.Fatalf("expected decompression error")
+	}
+}
+
+func TestWithDecompressor(t *testing.T) {
+	source, err := NewFsSource(os.DirFS("test"), "compressed-migrations",
+		WithDecompressor(".b64", func(r io.Reader) (io.ReadCloser, error) {
+			return io.NopCloser(base64.NewDecoder(base64.StdEncoding, r)), nil
+		}))
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+
+	up, _, err := source.ReadUp(3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer up.Close()
+	contents, _ := ioutil.ReadAll(up)
+	if bytes.Compare(contents, []byte("{\"3\": \"up\"}")) != 0 {
+		t.Fatalf("unexpected contents, got: %s", contents)
+	}
+}
+
+func Test_fsSource_SingleFileMigrations(t *testing.T) {
+	source, err := NewFsSource(os.DirFS("test"), "single-file-migrations", WithSingleFileMigrations(true))
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+	s := source.(*fsSource)
+	if len(s.migrations.index) != 3 {
+		t.Fatalf("expected migrations index to have length of 3, got: %v", s.migrations.index)
+	}
+
+	up, identifier, err := s.ReadUp(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if identifier != "init" {
+		t.Fatalf("expected identifier to be init, got: %s", identifier)
+	}
+	contents, _ := ioutil.ReadAll(up)
+	if string(contents) != "CREATE TABLE users (id INT);\n\n" {
+		t.Fatalf("unexpected contents, got: %q", contents)
+	}
+
+	down, _, err := s.ReadDown(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	contents, _ = ioutil.ReadAll(down)
+	if string(contents) != "DROP TABLE users;\n" {
+		t.Fatalf("unexpected contents, got: %q", contents)
+	}
+
+	down, _, err = s.ReadDown(2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	contents, _ = ioutil.ReadAll(down)
+	if string(contents) != "DROP TABLE mixed;\n" {
+		t.Fatalf("unexpected contents, got: %q", contents)
+	}
+
+	if _, _, err := s.ReadUp(3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, _, err := s.ReadDown(3); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got: %v", err)
+	}
+}
+
+func Test_fsSource_SingleFileMigrations_Disabled(t *testing.T) {
+	s := getTestSource(t, "single-file-migrations")
+	if len(s.migrations.index) != 1 {
+		t.Fatalf("expected migrations index to have length of 1, got: %v", s.migrations.index)
+	}
+}
+
+func Test_fsSource_SectionMarkers(t *testing.T) {
+	source, err := NewFsSource(os.DirFS("test"), "goose-migrations", WithSectionMarkers("-- +goose Up", "-- +goose Down"))
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+
+	up, identifier, err := source.ReadUp(20170506082420)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if identifier != "create" {
+		t.Fatalf("expected identifier to be create, got: %s", identifier)
+	}
+	contents, _ := ioutil.ReadAll(up)
+	if string(contents) != "CREATE TABLE post (id INT);\n" {
+		t.Fatalf("unexpected contents, got: %q", contents)
+	}
+}
+
+func Test_fsSource_read_SectionMissing(t *testing.T) {
+	source, _ := NewFsSource(os.DirFS("test"), "single-file-migrations", WithSingleFileMigrations(true))
+	s := source.(*fsSource)
+
+	_, err := s.read(&migration{Version: 4, Direction: Up, Raw: "004_no-markers.txt", Section: true})
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got: %v", err)
+	}
+	_, err = s.read(&migration{Version: 5, Direction: Up, Raw: "005_no-such-file.sql", Section: true})
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got: %v", err)
+	}
+}
+
+func Test_fsSource_FileNameParser(t *testing.T) {
+	source, err := NewFsSource(os.DirFS("test"), "flyway-migrations", WithFileNameParser(FlywayFileNameParser))
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+	s := source.(*fsSource)
+	if len(s.migrations.index) != 2 {
+		t.Fatalf("expected migrations index to have length of 2, got: %v", s.migrations.index)
+	}
+
+	down, identifier, err := s.ReadDown(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if identifier != "create_a" {
+		t.Fatalf("expected identifier to be create_a, got: %s", identifier)
+	}
+	contents, _ := ioutil.ReadAll(down)
+	if string(contents) != "DROP TABLE a;\n" {
+		t.Fatalf("unexpected contents, got: %q", contents)
+	}
+	if _, _, err := s.ReadDown(2); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got: %v", err)
+	}
+}
+
+func Test_fsSource_FileNameParser_Sections(t *testing.T) {
+	source, err := NewFsSource(os.DirFS("test"), "goose-migrations", WithFileNameParser(GooseFileNameParser),
+		WithSectionMarkers("-- +goose Up", "-- +goose Down"))
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+
+	down, _, err := source.ReadDown(20170506082420)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	contents, _ := ioutil.ReadAll(down)
+	if string(contents) != "DROP TABLE post;\n" {
+		t.Fatalf("unexpected contents, got: %q", contents)
+	}
+}
+
+func Test_fsSource_FileNameParser_NoSections(t *testing.T) {
+	_, err := NewFsSource(os.DirFS("test"), "goose-migrations", WithFileNameParser(GooseFileNameParser))
+	if !errors.Is(err, ErrNoSections) {
+		t.Fatalf("expected ErrNoSections, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "20170506082420_create.sql") {
+		t.Fatalf("expected file name in error, got: %v", err)
+	}
+}
+
+func Test_fsSource_FileNameParser_Zero(t *testing.T) {
+	source, err := NewFsSource(os.DirFS("test"), "sample-migrations",
+		WithFileNameParser(func(name string) (uint64, string, Direction, error) {
+			return NoMigrationVersion, name, Up, nil
+		}))
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+	if len(source.(*fsSource).migrations.index) != 0 {
+		t.Fatalf("expected no migrations, got: %v", source.(*fsSource).migrations.index)
+	}
+}
+
+func Test_fsSource_Recursive(t *testing.T) {
+	source, err := NewFsSource(os.DirFS("test"), "recursive-migrations", WithRecursive(true))
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+	s := source.(*fsSource)
+	if !reflect.DeepEqual(s.migrations.index, []uint64{1, 2, 3}) {
+		t.Fatalf("unexpected migrations index, got: %v", s.migrations.index)
+	}
+
+	up, identifier, err := s.ReadUp(3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if identifier != "b" {
+		t.Fatalf("expected identifier to be b, got: %s", identifier)
+	}
+	contents, _ := ioutil.ReadAll(up)
+	if string(contents) != `{"3": "up"}` {
+		t.Fatalf("unexpected contents, got: %s", contents)
+	}
+}
+
+func Test_fsSource_Recursive_Root(t *testing.T) {
+	source, err := NewFsSource(os.DirFS("test/recursive-migrations"), ".", WithRecursive(true))
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+
+	up, _, err := source.ReadUp(2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	contents, _ := ioutil.ReadAll(up)
+	if string(contents) != `{"2": "up"}` {
+		t.Fatalf("unexpected contents, got: %s", contents)
+	}
+}
+
+func Test_fsSource_Recursive_Duplicate(t *testing.T) {
+	_, err := NewFsSource(os.DirFS("test"), "duplicate-tree-migrations", WithRecursive(true))
+	if !errors.As(err, &ErrDuplicateMigration{}) {
+		t.Fatalf("expected ErrDuplicateMigration, got: %v", err)
+	}
+
+	_, err = NewFsSource(os.DirFS("test"), "duplicate-tree-migrations")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func Test_fsSource_VersionDirectories(t *testing.T) {
+	source, err := NewFsSource(os.DirFS("test"), "recursive-migrations", WithVersionDirectories(true))
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+	s := source.(*fsSource)
+	if !reflect.DeepEqual(s.migrations.index, []uint64{1, 4, 5}) {
+		t.Fatalf("unexpected migrations index, got: %v", s.migrations.index)
+	}
+
+	down, identifier, err := s.ReadDown(4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if identifier != "add_users" {
+		t.Fatalf("expected identifier to be add_users, got: %s", identifier)
+	}
+	contents, _ := ioutil.ReadAll(down)
+	if string(contents) != "DROP TABLE users;\n" {
+		t.Fatalf("unexpected contents, got: %q", contents)
+	}
+
+	if _, _, err := s.ReadDown(5); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got: %v", err)
+	}
+}
+
+func Test_fsSource_VersionDirectories_Recursive(t *testing.T) {
+	source, err := NewFsSource(os.DirFS("test"), "recursive-migrations", WithVersionDirectories(true), WithRecursive(true))
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+	s := source.(*fsSource)
+	if !reflect.DeepEqual(s.migrations.index, []uint64{1, 2, 3, 4, 5}) {
+		t.Fatalf("unexpected migrations index, got: %v", s.migrations.index)
+	}
+}
+
+func Test_fsSource_VersionDirectories_Grouped(t *testing.T) {
+	source, err := NewFsSource(os.DirFS("test"), "grouped-migrations", WithVersionDirectories(true), WithRecursive(true))
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+	s := source.(*fsSource)
+	if !reflect.DeepEqual(s.migrations.index, []uint64{1, 5, 6, 7}) {
+		t.Fatalf("unexpected migrations index, got: %v", s.migrations.index)
+	}
+	if _, identifier, err := s.ReadDown(5); err != nil || identifier != "users" {
+		t.Fatalf("unexpected down migration: %s (%v)", identifier, err)
+	}
+
+	source, err = NewFsSource(os.DirFS("test"), "grouped-migrations", WithVersionDirectories(true))
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+	if !reflect.DeepEqual(source.(*fsSource).migrations.index, []uint64{1}) {
+		t.Fatalf("unexpected migrations index, got: %v", source.(*fsSource).migrations.index)
+	}
+}
+
+func Test_fsSource_VersionDirectories_Duplicate(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/001_a.up.sql":      {Data: []byte("a")},
+		"migrations/0001_dir/up.sql":   {Data: []byte("b")},
+		"migrations/0000_zero/up.sql":  {Data: []byte("c")},
+		"migrations/0002_dir/down.sql": {Data: []byte("d")},
+	}
+
+	_, err := NewFsSource(fsys, "migrations", WithVersionDirectories(true))
+	if !errors.As(err, &ErrDuplicateMigration{}) {
+		t.Fatalf("expected ErrDuplicateMigration, got: %v", err)
+	}
+
+	delete(fsys, "migrations/001_a.up.sql")
+	source, err := NewFsSource(fsys, "migrations", WithVersionDirectories(true))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(source.(*fsSource).migrations.index, []uint64{1, 2}) {
+		t.Fatalf("unexpected migrations index, got: %v", source.(*fsSource).migrations.index)
+	}
+}
@@ -0,0 +1,146 @@
+package lightmigrate
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type memorySource struct {
+	migrations *migrations
+
+	files map[string][]byte
+	name  string
+}
+
+// NewMemorySource returns a new MigrationSource from a map of file names and migration bodies.
+// File names must match the Regex pattern, other files are ignored.
+func NewMemorySource(files map[string]string) (MigrationSource, error) {
+	contents := make(map[string][]byte, len(files))
+	for name, body := range files {
+		contents[name] = []byte(body)
+	}
+
+	return newMemorySource(contents, "memory")
+}
+
+// newMemorySource returns a new in-memory source, name is used in error messages.
+func newMemorySource(files map[string][]byte, name string) (*memorySource, error) {
+	m := &memorySource{
+		migrations: newMigrations(),
+		files:      files,
+		name:       name,
+	}
+
+	err := m.init()
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// init parses all file names and builds the migration index.
+func (m *memorySource) init() error {
+	names := make([]string, 0, len(m.files))
+	for name := range m.files {
+		names = append(names, name)
+	}
+	sort.Strings(names) // stable duplicate detection
+
+	for _, name := range names {
+		mig, err := parseFileName(name)
+		if err != nil {
+			continue
+		}
+		if !m.migrations.Append(mig) {
+			return ErrDuplicateMigration{
+				migration: *mig,
+				FileInfo:  memoryFileInfo{name: name, size: int64(len(m.files[name]))},
+			}
+		}
+	}
+
+	return nil
+}
+
+// Close is part of source.Driver interface implementation.
+func (m *memorySource) Close() error {
+	return nil
+}
+
+// First is part of source.Driver interface implementation.
+func (m *memorySource) First() (version uint64, err error) {
+	if version, ok := m.migrations.First(); ok {
+		return version, nil
+	}
+	return 0, &fs.PathError{
+		Op:   "first",
+		Path: m.name,
+		Err:  fs.ErrNotExist,
+	}
+}
+
+// Prev is part of source.Driver interface implementation.
+func (m *memorySource) Prev(version uint64) (prevVersion uint64, err error) {
+	if version, ok := m.migrations.Prev(version); ok {
+		return version, nil
+	}
+	return 0, &fs.PathError{
+		Op:   "prev for version " + strconv.FormatUint(version, 10),
+		Path: m.name,
+		Err:  fs.ErrNotExist,
+	}
+}
+
+// Next is part of source.Driver interface implementation.
+func (m *memorySource) Next(version uint64) (nextVersion uint64, err error) {
+	if version, ok := m.migrations.Next(version); ok {
+		return version, nil
+	}
+	return 0, &fs.PathError{
+		Op:   "next for version " + strconv.FormatUint(version, 10),
+		Path: m.name,
+		Err:  fs.ErrNotExist,
+	}
+}
+
+// ReadUp is part of source.Driver interface implementation.
+func (m *memorySource) ReadUp(version uint64) (r io.ReadCloser, identifier string, err error) {
+	if mig, ok := m.migrations.Up(version); ok {
+		return io.NopCloser(bytes.NewReader(m.files[mig.Raw])), mig.Identifier, nil
+	}
+	return nil, "", &fs.PathError{
+		Op:   "read up for version " + strconv.FormatUint(version, 10),
+		Path: m.name,
+		Err:  fs.ErrNotExist,
+	}
+}
+
+// ReadDown is part of source.Driver interface implementation.
+func (m *memorySource) ReadDown(version uint64) (r io.ReadCloser, identifier string, err error) {
+	if mig, ok := m.migrations.Down(version); ok {
+		return io.NopCloser(bytes.NewReader(m.files[mig.Raw])), mig.Identifier, nil
+	}
+	return nil, "", &fs.PathError{
+		Op:   "read down for version " + strconv.FormatUint(version, 10),
+		Path: m.name,
+		Err:  fs.ErrNotExist,
+	}
+}
+
+// memoryFileInfo describes an in-memory migration file.
+type memoryFileInfo struct {
+	name string
+	size int64
+}
+
+func (m memoryFileInfo) Name() string       { return m.name }
+func (m memoryFileInfo) Size() int64        { return m.size }
+func (m memoryFileInfo) Mode() fs.FileMode  { return 0444 }
+func (m memoryFileInfo) ModTime() time.Time { return time.Time{} }
+func (m memoryFileInfo) IsDir() bool        { return false }
+func (m memoryFileInfo) Sys() interface{}   { return nil }
@@ -0,0 +1,136 @@
+package lightmigrate
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+
+	"github.com/h44z/lightmigrate/test"
+)
+
+func getTestMemorySource(t *testing.T) MigrationSource {
+	s, err := NewMemorySource(map[string]string{
+		"001_first.up.sql":    "CREATE TABLE first;",
+		"001_first.down.sql":  "DROP TABLE first;",
+		"003_third.up.sql":    "CREATE TABLE third;",
+		"003_third.down.sql":  "DROP TABLE third;",
+		"readme.md":           "ignored",
+		"0_invalid.up.sql":    "ignored",
+		"not_a_migration.sql": "ignored",
+	})
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+	return s
+}
+
+func TestNewMemorySource(t *testing.T) {
+	s := getTestMemorySource(t)
+	defer s.Close()
+
+	if len(s.(*memorySource).migrations.index) != 2 {
+		t.Fatalf("expected 2 migrations, got: %v", s.(*memorySource).migrations.index)
+	}
+}
+
+func TestNewMemorySource_Duplicate(t *testing.T) {
+	_, err := NewMemorySource(map[string]string{
+		"001_first.up.sql":  "",
+		"001_second.up.sql": "",
+	})
+	var dup ErrDuplicateMigration
+	if !errors.As(err, &dup) {
+		t.Fatalf("expected ErrDuplicateMigration, got: %v", err)
+	}
+	if dup.Error() != "duplicate migration file: 001_second.up.sql" {
+		t.Fatalf("unexpected error message: %v", dup)
+	}
+}
+
+func Test_memorySource_Navigation(t *testing.T) {
+	s := getTestMemorySource(t)
+
+	first, err := s.First()
+	if err != nil || first != 1 {
+		t.Fatalf("expected first version 1, got: %d (%v)", first, err)
+	}
+	next, err := s.Next(1)
+	if err != nil || next != 3 {
+		t.Fatalf("expected next version 3, got: %d (%v)", next, err)
+	}
+	prev, err := s.Prev(3)
+	if err != nil || prev != 1 {
+		t.Fatalf("expected prev version 1, got: %d (%v)", prev, err)
+	}
+	if _, err := s.Next(3); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got: %v", err)
+	}
+	if _, err := s.Prev(1); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got: %v", err)
+	}
+
+	empty, _ := NewMemorySource(nil)
+	if _, err := empty.First(); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got: %v", err)
+	}
+}
+
+func Test_memorySource_Read(t *testing.T) {
+	s := getTestMemorySource(t)
+
+	up, identifier, err := s.ReadUp(3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if identifier != "third" {
+		t.Fatalf("expected identifier third, got: %s", identifier)
+	}
+	contents, _ := io.ReadAll(up)
+	if string(contents) != "CREATE TABLE third;" {
+		t.Fatalf("unexpected contents, got: %s", contents)
+	}
+
+	down, _, err := s.ReadDown(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	contents, _ = io.ReadAll(down)
+	if string(contents) != "DROP TABLE first;" {
+		t.Fatalf("unexpected contents, got: %s", contents)
+	}
+
+	if _, _, err := s.ReadUp(2); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got: %v", err)
+	}
+	if _, _, err := s.ReadDown(2); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got: %v", err)
+	}
+}
+
+func Test_memorySource_Migrate(t *testing.T) {
+	s := getTestMemorySource(t)
+	d, _ := test.NewMockDriver()
+
+	m, _ := NewMigrator(s, d)
+	if err := m.Migrate(3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := m.Migrate(NoMigrationVersion); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.Version != NoMigrationVersion {
+		t.Fatalf("expected version 0, got: %d", d.Version)
+	}
+}
+
+func Test_memoryFileInfo(t *testing.T) {
+	fi := memoryFileInfo{name: "001_test.up.sql", size: 4}
+
+	if fi.Name() != "001_test.up.sql" || fi.Size() != 4 || fi.IsDir() || !fi.ModTime().IsZero() {
+		t.Fatalf("unexpected file info: %v", fi)
+	}
+	if fi.Mode() != 0444 || fi.Sys() != nil {
+		t.Fatalf("unexpected file info: %v", fi)
+	}
+}
@@ -0,0 +1,163 @@
+package lightmigrate
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+)
+
+type multiSource struct {
+	migrations *migrations
+
+	// sources stores the owning source for each version and direction
+	sources map[uint64]map[Direction]MigrationSource
+	all     []MigrationSource
+}
+
+// NewMultiSource returns a new MigrationSource that merges the migrations of multiple sources.
+// Each version and direction must only be defined by a single source.
+func NewMultiSource(sources ...MigrationSource) (MigrationSource, error) {
+	s := &multiSource{
+		migrations: newMigrations(),
+		sources:    make(map[uint64]map[Direction]MigrationSource),
+		all:        sources,
+	}
+
+	for _, source := range sources {
+		err := s.init(source)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// init adds all migrations of the given source to the index.
+func (s *multiSource) init(source MigrationSource) error {
+	version, err := source.First()
+	for err == nil {
+		for _, direction := range []Direction{Up, Down} {
+			addErr := s.add(source, version, direction)
+			if addErr != nil {
+				return addErr
+			}
+		}
+		version, err = source.Next(version)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// add registers the migration with the given version and direction if it exists in the source.
+func (s *multiSource) add(source MigrationSource, version uint64, direction Direction) error {
+	var err error
+	var contents io.ReadCloser
+	var identifier string
+
+	switch direction {
+	case Up:
+		contents, identifier, err = source.ReadUp(version)
+	case Down:
+		contents, identifier, err = source.ReadDown(version)
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return nil // no migration for this direction
+	}
+	if err != nil {
+		return err
+	}
+	_ = contents.Close()
+
+	if !s.migrations.Append(&migration{Version: version, Identifier: identifier, Direction: direction}) {
+		return ErrDuplicateSourceMigration{
+			Version:    version,
+			Direction:  direction,
+			Identifier: identifier,
+		}
+	}
+
+	if s.sources[version] == nil {
+		s.sources[version] = make(map[Direction]MigrationSource)
+	}
+	s.sources[version][direction] = source
+
+	return nil
+}
+
+// Close is part of source.Driver interface implementation.
+// Closes all underlying sources.
+func (s *multiSource) Close() error {
+	var err error
+	for _, source := range s.all {
+		if closeErr := source.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// First is part of source.Driver interface implementation.
+func (s *multiSource) First() (version uint64, err error) {
+	if version, ok := s.migrations.First(); ok {
+		return version, nil
+	}
+	return 0, &fs.PathError{
+		Op:   "first",
+		Path: "multi",
+		Err:  fs.ErrNotExist,
+	}
+}
+
+// Prev is part of source.Driver interface implementation.
+func (s *multiSource) Prev(version uint64) (prevVersion uint64, err error) {
+	if version, ok := s.migrations.Prev(version); ok {
+		return version, nil
+	}
+	return 0, &fs.PathError{
+		Op:   "prev for version " + strconv.FormatUint(version, 10),
+		Path: "multi",
+		Err:  fs.ErrNotExist,
+	}
+}
+
+// Next is part of source.Driver interface implementation.
+func (s *multiSource) Next(version uint64) (nextVersion uint64, err error) {
+	if version, ok := s.migrations.Next(version); ok {
+		return version, nil
+	}
+	return 0, &fs.PathError{
+		Op:   "next for version " + strconv.FormatUint(version, 10),
+		Path: "multi",
+		Err:  fs.ErrNotExist,
+	}
+}
+
+// ReadUp is part of source.Driver interface implementation.
+func (s *multiSource) ReadUp(version uint64) (r io.ReadCloser, identifier string, err error) {
+	if _, ok := s.migrations.Up(version); ok {
+		return s.sources[version][Up].ReadUp(version)
+	}
+	return nil, "", &fs.PathError{
+		Op:   "read up for version " + strconv.FormatUint(version, 10),
+		Path: "multi",
+		Err:  fs.ErrNotExist,
+	}
+}
+
+// ReadDown is part of source.Driver interface implementation.
+func (s *multiSource) ReadDown(version uint64) (r io.ReadCloser, identifier string, err error) {
+	if _, ok := s.migrations.Down(version); ok {
+		return s.sources[version][Down].ReadDown(version)
+	}
+	return nil, "", &fs.PathError{
+		Op:   "read down for version " + strconv.FormatUint(version, 10),
+		Path: "multi",
+		Err:  fs.ErrNotExist,
+	}
+}
@@ -0,0 +1,162 @@
+package lightmigrate
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"reflect"
+	"testing"
+
+	"github.com/h44z/lightmigrate/test"
+)
+
+func getTestMultiSource(t *testing.T) MigrationSource {
+	funcs := NewFuncSource()
+	_ = funcs.Register(5, "func", func(ctx context.Context, driver MigrationDriver) error { return nil }, nil)
+
+	s, err := NewMultiSource(getTestSource(t, "sample-migrations"), getTestSource(t, "sparse-migrations"), funcs)
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+	return s
+}
+
+func TestNewMultiSource(t *testing.T) {
+	s := getTestMultiSource(t)
+	defer s.Close()
+
+	versions := make([]uint64, 0)
+	version, err := s.First()
+	for err == nil {
+		versions = append(versions, version)
+		version, err = s.Next(version)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got: %v", err)
+	}
+	if !reflect.DeepEqual(versions, []uint64{1, 2, 3, 5, 10, 20, 30}) {
+		t.Fatalf("unexpected versions: %v", versions)
+	}
+
+	prev, err := s.Prev(10)
+	if err != nil || prev != 5 {
+		t.Fatalf("expected prev version 5, got: %d (%v)", prev, err)
+	}
+	if _, err := s.Prev(1); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got: %v", err)
+	}
+}
+
+func TestNewMultiSource_Empty(t *testing.T) {
+	s, err := NewMultiSource(getTestSource(t, "no-migrations"))
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+	if _, err := s.First(); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got: %v", err)
+	}
+}
+
+func TestNewMultiSource_Duplicate(t *testing.T) {
+	_, err := NewMultiSource(getTestSource(t, "sample-migrations"), getTestSource(t, "sample-migrations"))
+	var dup ErrDuplicateSourceMigration
+	if !errors.As(err, &dup) {
+		t.Fatalf("expected ErrDuplicateSourceMigration, got: %v", err)
+	}
+	if dup.Version != 1 || dup.Direction != Up {
+		t.Fatalf("unexpected duplicate: %v", dup)
+	}
+}
+
+func TestNewMultiSource_Incomplete(t *testing.T) {
+	// the up and down migration of a version may be provided by different sources
+	funcs := NewFuncSource()
+	_ = funcs.Register(3, "func", func(ctx context.Context, driver MigrationDriver) error { return nil }, nil)
+
+	s, err := NewMultiSource(getTestSource(t, "incomplete-migrations"), funcs)
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+
+	_, identifier, err := s.ReadUp(3)
+	if err != nil || identifier != "func" {
+		t.Fatalf("expected func up migration, got: %s (%v)", identifier, err)
+	}
+	_, identifier, err = s.ReadDown(3)
+	if err != nil || identifier != "no-up" {
+		t.Fatalf("expected file down migration, got: %s (%v)", identifier, err)
+	}
+}
+
+func TestNewMultiSource_SourceError(t *testing.T) {
+	s, _ := test.NewMockSource(1, 2)
+	s.Error = errors.New("sourceerror")
+
+	_, err := NewMultiSource(s)
+	if err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+}
+
+func Test_multiSource_Read(t *testing.T) {
+	s := getTestMultiSource(t)
+
+	up, identifier, err := s.ReadUp(20)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if identifier != "v20" {
+		t.Fatalf("expected identifier v20, got: %s", identifier)
+	}
+	contents, _ := io.ReadAll(up)
+	_ = up.Close()
+	if string(contents) != `{"20": "up"}` {
+		t.Fatalf("unexpected contents, got: %s", contents)
+	}
+
+	down, identifier, err := s.ReadDown(2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if identifier != "another text" {
+		t.Fatalf("expected identifier another text, got: %s", identifier)
+	}
+	_ = down.Close()
+
+	if _, _, err := s.ReadDown(5); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got: %v", err)
+	}
+	if _, _, err := s.ReadUp(4); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got: %v", err)
+	}
+}
+
+func Test_multiSource_Close(t *testing.T) {
+	closeErr := errors.New("closeerror")
+	s1, _ := test.NewMockSource(1, 2)
+	s2, _ := test.NewMockSource(3, 4)
+	s := &multiSource{all: []MigrationSource{s1, s2}}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s2.Error = closeErr
+	if err := s.Close(); err != closeErr {
+		t.Fatalf("expected close error, got: %v", err)
+	}
+}
+
+func Test_multiSource_Migrate(t *testing.T) {
+	s := getTestMultiSource(t)
+	d, _ := test.NewMockDriver()
+
+	m, _ := NewMigrator(s, d)
+	if err := m.Migrate(30); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.Version != 30 {
+		t.Fatalf("expected version 30, got: %d", d.Version)
+	}
+}
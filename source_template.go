@@ -0,0 +1,153 @@
+package lightmigrate
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// varRegex matches ${NAME} variables. Other $ signs, like $1 or the $$ quotes of PostgreSQL function
+// bodies, are not expanded.
+var varRegex = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+type templateSource struct {
+	MigrationSource
+
+	data      map[string]interface{}
+	env       bool
+	expansion bool
+}
+
+// TemplateSourceOption is a function that can be used within the template source constructor to
+// modify the source object.
+type TemplateSourceOption func(s *templateSource)
+
+// WithTemplateEnv enables the environment lookup. Templates can use the env function ({{ env "NAME" }}),
+// with variable expansion, variables missing in the data map are looked up in the environment.
+func WithTemplateEnv(enabled bool) TemplateSourceOption {
+	return func(s *templateSource) {
+		s.env = enabled
+	}
+}
+
+// WithVarExpansion replaces text/template rendering with ${VAR} expansion. Only the braced form is
+// expanded, $VAR, $1 and $$ are left untouched.
+func WithVarExpansion(enabled bool) TemplateSourceOption {
+	return func(s *templateSource) {
+		s.expansion = enabled
+	}
+}
+
+// NewTemplateSource returns a new MigrationSource that renders all migration bodies of the given source
+// with text/template before they are passed to the driver. The data map is used as template data.
+// Missing template keys or variables result in an error. Go migrations of a FuncSource are not rendered.
+func NewTemplateSource(source MigrationSource, data map[string]interface{}, opts ...TemplateSourceOption) (MigrationSource, error) {
+	s := &templateSource{
+		MigrationSource: source,
+		data:            data,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s, nil
+}
+
+// ReadUp is part of source.Driver interface implementation.
+func (s *templateSource) ReadUp(version uint64) (r io.ReadCloser, identifier string, err error) {
+	r, identifier, err = s.MigrationSource.ReadUp(version)
+	if err != nil {
+		return nil, "", err
+	}
+	r, err = s.render(r, identifier)
+	return r, identifier, err
+}
+
+// ReadDown is part of source.Driver interface implementation.
+func (s *templateSource) ReadDown(version uint64) (r io.ReadCloser, identifier string, err error) {
+	r, identifier, err = s.MigrationSource.ReadDown(version)
+	if err != nil {
+		return nil, "", err
+	}
+	r, err = s.render(r, identifier)
+	return r, identifier, err
+}
+
+// render reads the whole migration body and returns the rendered body.
+func (s *templateSource) render(r io.ReadCloser, identifier string) (io.ReadCloser, error) {
+	if _, ok := r.(*funcBody); ok {
+		return r, nil // nothing to render
+	}
+
+	defer r.Close()
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var rendered []byte
+	if s.expansion {
+		rendered, err = s.expand(body)
+	} else {
+		rendered, err = s.execute(body, identifier)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to render migration %s: %w", identifier, err)
+	}
+
+	return io.NopCloser(bytes.NewReader(rendered)), nil
+}
+
+// execute renders the body with text/template.
+func (s *templateSource) execute(body []byte, identifier string) ([]byte, error) {
+	funcs := template.FuncMap{
+		"env": func(name string) (string, error) {
+			if !s.env {
+				return "", fmt.Errorf("environment lookup is disabled")
+			}
+			return os.Getenv(name), nil
+		},
+	}
+
+	tpl, err := template.New(identifier).Funcs(funcs).Option("missingkey=error").Parse(string(body))
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	err = tpl.Execute(buf, s.data)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// expand replaces ${VAR} in the body.
+func (s *templateSource) expand(body []byte) ([]byte, error) {
+	missing := make([]string, 0)
+	rendered := varRegex.ReplaceAllStringFunc(string(body), func(variable string) string {
+		name := variable[2 : len(variable)-1]
+		if value, ok := s.data[name]; ok {
+			return fmt.Sprint(value)
+		}
+		if s.env {
+			if value, ok := os.LookupEnv(name); ok {
+				return value
+			}
+		}
+		missing = append(missing, name)
+		return ""
+	})
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("undefined variables: %s", strings.Join(missing, ", "))
+	}
+
+	return []byte(rendered), nil
+}
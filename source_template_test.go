@@ -0,0 +1,124 @@
+package lightmigrate
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+)
+
+func readTestTemplate(t *testing.T, s MigrationSource, version uint64, direction Direction) (string, error) {
+	var r io.ReadCloser
+	var err error
+	switch direction {
+	case Up:
+		r, _, err = s.ReadUp(version)
+	case Down:
+		r, _, err = s.ReadDown(version)
+	}
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	contents, _ := io.ReadAll(r)
+	return string(contents), nil
+}
+
+func TestNewTemplateSource(t *testing.T) {
+	mem, _ := NewMemorySource(map[string]string{
+		"001_init.up.sql":    "CREATE TABLE {{ .prefix }}_users;",
+		"001_init.down.sql":  "DROP TABLE {{ .prefix }}_users;",
+		"002_env.up.sql":     `-- {{ env "LIGHTMIGRATE_TEST_VAR" }}`,
+		"003_missing.up.sql": "CREATE TABLE {{ .missing }};",
+		"004_invalid.up.sql": "CREATE TABLE {{ .prefix ;",
+	})
+	s, err := NewTemplateSource(mem, map[string]interface{}{"prefix": "tenant1"})
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+
+	if got, err := readTestTemplate(t, s, 1, Up); err != nil || got != "CREATE TABLE tenant1_users;" {
+		t.Fatalf("unexpected rendered up migration: %s (%v)", got, err)
+	}
+	if got, err := readTestTemplate(t, s, 1, Down); err != nil || got != "DROP TABLE tenant1_users;" {
+		t.Fatalf("unexpected rendered down migration: %s (%v)", got, err)
+	}
+	if _, err := readTestTemplate(t, s, 2, Up); err == nil {
+		t.Fatalf("expected disabled environment error")
+	}
+	if _, err := readTestTemplate(t, s, 3, Up); err == nil {
+		t.Fatalf("expected missing key error")
+	}
+	if _, err := readTestTemplate(t, s, 4, Up); err == nil {
+		t.Fatalf("expected template parse error")
+	}
+	if _, err := readTestTemplate(t, s, 5, Up); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got: %v", err)
+	}
+	if _, err := readTestTemplate(t, s, 5, Down); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got: %v", err)
+	}
+
+	// navigation is passed to the underlying source
+	if next, err := s.Next(1); err != nil || next != 2 {
+		t.Fatalf("expected next version 2, got: %d (%v)", next, err)
+	}
+}
+
+func TestNewTemplateSource_Env(t *testing.T) {
+	t.Setenv("LIGHTMIGRATE_TEST_VAR", "from env")
+	mem, _ := NewMemorySource(map[string]string{
+		"001_env.up.sql": `-- {{ env "LIGHTMIGRATE_TEST_VAR" }}`,
+	})
+	s, _ := NewTemplateSource(mem, nil, WithTemplateEnv(true))
+
+	if got, err := readTestTemplate(t, s, 1, Up); err != nil || got != "-- from env" {
+		t.Fatalf("unexpected rendered migration: %s (%v)", got, err)
+	}
+}
+
+func TestNewTemplateSource_VarExpansion(t *testing.T) {
+	t.Setenv("LIGHTMIGRATE_TEST_VAR", "from env")
+	mem, _ := NewMemorySource(map[string]string{
+		"001_init.up.sql": "CREATE TABLE ${prefix}_users; -- $count {{ .ignored }}",
+		"002_env.up.sql":  "-- ${LIGHTMIGRATE_TEST_VAR}",
+		"003_miss.up.sql": "-- ${missing1} ${missing2}",
+		"004_func.up.sql": "CREATE FUNCTION ${prefix}_inc(i int) RETURNS int AS $$ BEGIN RETURN $1 + 1; END; $$ LANGUAGE plpgsql;",
+	})
+
+	s, _ := NewTemplateSource(mem, map[string]interface{}{"prefix": "tenant1", "count": 3}, WithVarExpansion(true))
+	if got, err := readTestTemplate(t, s, 1, Up); err != nil || got != "CREATE TABLE tenant1_users; -- $count {{ .ignored }}" {
+		t.Fatalf("unexpected rendered migration: %s (%v)", got, err)
+	}
+	want := "CREATE FUNCTION tenant1_inc(i int) RETURNS int AS $$ BEGIN RETURN $1 + 1; END; $$ LANGUAGE plpgsql;"
+	if got, err := readTestTemplate(t, s, 4, Up); err != nil || got != want {
+		t.Fatalf("unexpected rendered function: %s (%v)", got, err)
+	}
+	if _, err := readTestTemplate(t, s, 2, Up); err == nil {
+		t.Fatalf("expected undefined variable error")
+	}
+	_, err := readTestTemplate(t, s, 3, Up)
+	if err == nil || err.Error() != "failed to render migration miss: undefined variables: missing1, missing2" {
+		t.Fatalf("expected undefined variable error, got: %v", err)
+	}
+
+	s, _ = NewTemplateSource(mem, nil, WithVarExpansion(true), WithTemplateEnv(true))
+	if got, err := readTestTemplate(t, s, 2, Up); err != nil || got != "-- from env" {
+		t.Fatalf("unexpected rendered migration: %s (%v)", got, err)
+	}
+}
+
+func TestNewTemplateSource_FuncSource(t *testing.T) {
+	funcs := NewFuncSource()
+	_ = funcs.Register(1, "func", func(ctx context.Context, driver MigrationDriver) error { return nil }, nil)
+	s, _ := NewTemplateSource(funcs, nil)
+
+	r, _, err := s.ReadUp(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := r.(*funcBody); !ok {
+		t.Fatalf("expected function body, got: %T", r)
+	}
+}
@@ -0,0 +1,155 @@
+package sqldriver
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/crc32"
+	"strconv"
+	"strings"
+
+	"github.com/h44z/lightmigrate"
+)
+
+// Dialect contains the database specific parts of the driver.
+type Dialect interface {
+	// Placeholder returns the bind parameter placeholder for the n-th parameter, starting at 1.
+	Placeholder(n int) string
+
+	// QuoteIdentifier quotes a table name.
+	QuoteIdentifier(name string) string
+
+	// Lock acquires the migration lock for the given version table on the connection.
+	// If the database does not support locking, return nil.
+	// Return lightmigrate.ErrLocked if the database is already locked.
+	Lock(ctx context.Context, conn *sql.Conn, table string) error
+
+	// Unlock releases the migration lock for the given version table.
+	Unlock(ctx context.Context, conn *sql.Conn, table string) error
+}
+
+// TransactionDialect is an optional interface a Dialect can implement, if some statements cannot
+// be executed within a transaction. If a migration contains such statements, it is executed without
+// a transaction even if transactions are enabled. The statements are always split, even if statement
+// splitting is disabled for the execution.
+type TransactionDialect interface {
+	Dialect
+
+	// Transactional reports whether all statements can be executed within a transaction.
+	Transactional(statements []string) bool
+}
+
+// GenericDialect uses ? placeholders and ANSI quoted identifiers, locking is not supported.
+type GenericDialect struct{}
+
+// Placeholder is part of Dialect interface implementation.
+func (GenericDialect) Placeholder(_ int) string {
+	return "?"
+}
+
+// QuoteIdentifier is part of Dialect interface implementation.
+func (GenericDialect) QuoteIdentifier(name string) string {
+	return quoteIdentifier(name, '"')
+}
+
+// Lock is part of Dialect interface implementation.
+func (GenericDialect) Lock(_ context.Context, _ *sql.Conn, _ string) error {
+	return nil
+}
+
+// Unlock is part of Dialect interface implementation.
+func (GenericDialect) Unlock(_ context.Context, _ *sql.Conn, _ string) error {
+	return nil
+}
+
+// SQLiteDialect is the dialect for SQLite. SQLite only allows a single writer, so no additional
+// locking is required.
+type SQLiteDialect struct {
+	GenericDialect
+}
+
+// PostgresDialect uses $n placeholders and a session level advisory lock keyed by the version table name.
+type PostgresDialect struct{}
+
+// Placeholder is part of Dialect interface implementation.
+func (PostgresDialect) Placeholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+// QuoteIdentifier is part of Dialect interface implementation.
+func (PostgresDialect) QuoteIdentifier(name string) string {
+	return quoteIdentifier(name, '"')
+}
+
+// Lock is part of Dialect interface implementation.
+func (PostgresDialect) Lock(ctx context.Context, conn *sql.Conn, table string) error {
+	_, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", LockKey(table))
+	return err
+}
+
+// Unlock is part of Dialect interface implementation.
+// pg_advisory_unlock returns false if the lock was not held.
+func (PostgresDialect) Unlock(ctx context.Context, conn *sql.Conn, table string) error {
+	var released bool
+	err := conn.QueryRowContext(ctx, "SELECT pg_advisory_unlock($1)", LockKey(table)).Scan(&released)
+	if err != nil {
+		return err
+	}
+	if !released {
+		return fmt.Errorf("advisory lock for %s was not held", table)
+	}
+	return nil
+}
+
+// MySQLDialect uses ? placeholders, backtick quoted identifiers and named locks (GET_LOCK).
+type MySQLDialect struct{}
+
+// Placeholder is part of Dialect interface implementation.
+func (MySQLDialect) Placeholder(_ int) string {
+	return "?"
+}
+
+// QuoteIdentifier is part of Dialect interface implementation.
+func (MySQLDialect) QuoteIdentifier(name string) string {
+	return quoteIdentifier(name, '`')
+}
+
+// Lock is part of Dialect interface implementation.
+// GET_LOCK returns 1 if the lock was acquired, 0 on timeout and NULL on errors.
+func (MySQLDialect) Lock(ctx context.Context, conn *sql.Conn, table string) error {
+	var acquired sql.NullInt64
+	err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, 10)", lockName(table)).Scan(&acquired)
+	if err != nil {
+		return err
+	}
+	if !acquired.Valid || acquired.Int64 != 1 {
+		return lightmigrate.ErrLocked
+	}
+	return nil
+}
+
+// Unlock is part of Dialect interface implementation.
+func (MySQLDialect) Unlock(ctx context.Context, conn *sql.Conn, table string) error {
+	_, err := conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", lockName(table))
+	return err
+}
+
+// LockKey returns a numeric lock key for the given names, for example to be used with advisory locks.
+func LockKey(names ...string) int64 {
+	return int64(crc32.ChecksumIEEE([]byte(lockName(names...))))
+}
+
+// lockName returns a lock name for the given names.
+func lockName(names ...string) string {
+	return "lightmigrate:" + strings.Join(names, ":")
+}
+
+// quoteIdentifier quotes all parts of a (schema qualified) identifier.
+func quoteIdentifier(name string, quote byte) string {
+	q := string(quote)
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = q + strings.ReplaceAll(part, q, q+q) + q
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,426 @@
+// Package sqldriver implements a lightmigrate.MigrationDriver on top of database/sql.
+// Database specific statements, like locking, are provided by a Dialect.
+package sqldriver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/h44z/lightmigrate"
+)
+
+// DefaultVersionTable is the default name of the table that stores the version and dirty state.
+const DefaultVersionTable = "schema_migrations"
+
+// HistoryTableSuffix is appended to the version table name to get the default history table name.
+const HistoryTableSuffix = "_history"
+
+// Driver is a lightmigrate.MigrationDriver for database/sql databases.
+type Driver struct {
+	db   *sql.DB
+	conn *sql.Conn // all statements and locks use the same connection
+
+	dialect         Dialect
+	table           string
+	historyTable    string
+	transactions    bool
+	splitStatements bool
+
+	mux    sync.Mutex
+	locked bool
+
+	logger  lightmigrate.Logger
+	verbose bool
+}
+
+// DriverOption is a function that can be used within the driver constructor to
+// modify the driver object.
+type DriverOption func(d *Driver)
+
+// WithDialect sets the database dialect, GenericDialect is used by default.
+func WithDialect(dialect Dialect) DriverOption {
+	return func(d *Driver) {
+		d.dialect = dialect
+	}
+}
+
+// WithVersionTable sets the name of the table that stores the version and dirty state.
+// The name may be schema qualified, for example "app.schema_migrations".
+func WithVersionTable(table string) DriverOption {
+	return func(d *Driver) {
+		d.table = table
+	}
+}
+
+// WithHistoryTable sets the name of the table that stores the migration history, see lightmigrate.HistoryDriver.
+// By default, the version table name with the HistoryTableSuffix is used, e.g. "schema_migrations_history".
+func WithHistoryTable(table string) DriverOption {
+	return func(d *Driver) {
+		d.historyTable = table
+	}
+}
+
+// WithTransactions runs each migration in a transaction. Note that some databases (e.g. MySQL)
+// implicitly commit DDL statements.
+func WithTransactions(enabled bool) DriverOption {
+	return func(d *Driver) {
+		d.transactions = enabled
+	}
+}
+
+// WithStatementSplitting enables or disables splitting migrations into single statements.
+// Splitting is enabled by default, disable it if the database supports multiple statements per call.
+func WithStatementSplitting(enabled bool) DriverOption {
+	return func(d *Driver) {
+		d.splitStatements = enabled
+	}
+}
+
+// WithLogger sets the logging instance used by the driver.
+func WithLogger(logger lightmigrate.Logger) DriverOption {
+	return func(d *Driver) {
+		d.logger = logger
+	}
+}
+
+// WithVerboseLogging sets the verbose flag of the driver.
+func WithVerboseLogging(verbose bool) DriverOption {
+	return func(d *Driver) {
+		d.verbose = verbose
+	}
+}
+
+// NewDriver returns a new MigrationDriver for the given database. The version and history tables are
+// created if they do not exist. The database itself is not closed by Driver.Close.
+func NewDriver(db *sql.DB, opts ...DriverOption) (*Driver, error) {
+	d := &Driver{
+		db:              db,
+		dialect:         GenericDialect{},
+		table:           DefaultVersionTable,
+		splitStatements: true,
+		logger:          log.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+	if d.historyTable == "" {
+		d.historyTable = d.table + HistoryTableSuffix
+	}
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open connection: %w", err)
+	}
+	d.conn = conn
+
+	err = d.ensureVersionTable(ctx)
+	if err == nil {
+		err = d.ensureHistoryTable(ctx)
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// DB returns the underlying database, for example to be used in Go migrations.
+func (d *Driver) DB() *sql.DB {
+	return d.db
+}
+
+// ensureVersionTable creates the version table if it does not exist.
+func (d *Driver) ensureVersionTable(ctx context.Context) error {
+	query := "CREATE TABLE IF NOT EXISTS " + d.dialect.QuoteIdentifier(d.table) +
+		" (version BIGINT NOT NULL, dirty BOOLEAN NOT NULL)"
+	if _, err := d.conn.ExecContext(ctx, query); err != nil {
+		return lightmigrate.DriverError{OrigErr: err, Msg: "unable to create version table", Query: []byte(query)}
+	}
+	return nil
+}
+
+// ensureHistoryTable creates the history table if it does not exist. Times are stored as RFC 3339
+// strings, as time columns are not handled consistently by all database/sql drivers.
+func (d *Driver) ensureHistoryTable(ctx context.Context) error {
+	query := "CREATE TABLE IF NOT EXISTS " + d.dialect.QuoteIdentifier(d.historyTable) +
+		" (id BIGINT NOT NULL, version BIGINT NOT NULL, identifier VARCHAR(255) NOT NULL," +
+		" direction VARCHAR(4) NOT NULL, checksum VARCHAR(64) NOT NULL, started_at VARCHAR(35) NOT NULL," +
+		" finished_at VARCHAR(35) NOT NULL, applied_by VARCHAR(255) NOT NULL, PRIMARY KEY (id))"
+	if _, err := d.conn.ExecContext(ctx, query); err != nil {
+		return lightmigrate.DriverError{OrigErr: err, Msg: "unable to create history table", Query: []byte(query)}
+	}
+	return nil
+}
+
+// Close is part of lightmigrate.MigrationDriver interface implementation.
+// Only the connection of the driver is released, the database is not closed.
+func (d *Driver) Close() error {
+	return d.conn.Close()
+}
+
+// Lock is part of lightmigrate.MigrationDriver interface implementation.
+func (d *Driver) Lock() error {
+	return d.LockContext(context.Background())
+}
+
+// LockContext is part of lightmigrate.MigrationDriverContext interface implementation.
+func (d *Driver) LockContext(ctx context.Context) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	if d.locked {
+		return lightmigrate.ErrLocked
+	}
+
+	if err := d.dialect.Lock(ctx, d.conn, d.table); err != nil {
+		if errors.Is(err, lightmigrate.ErrLocked) {
+			return err
+		}
+		return fmt.Errorf("unable to acquire lock: %w", err)
+	}
+	d.locked = true
+
+	if d.verbose {
+		d.logger.Printf("acquired lock for %s", d.table)
+	}
+
+	return nil
+}
+
+// Unlock is part of lightmigrate.MigrationDriver interface implementation.
+func (d *Driver) Unlock() error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	if !d.locked {
+		return nil
+	}
+
+	if err := d.dialect.Unlock(context.Background(), d.conn, d.table); err != nil {
+		return fmt.Errorf("unable to release lock: %w", err)
+	}
+	d.locked = false
+
+	if d.verbose {
+		d.logger.Printf("released lock for %s", d.table)
+	}
+
+	return nil
+}
+
+// GetVersion is part of lightmigrate.MigrationDriver interface implementation.
+func (d *Driver) GetVersion() (version uint64, dirty bool, err error) {
+	query := "SELECT version, dirty FROM " + d.dialect.QuoteIdentifier(d.table) + " LIMIT 1"
+
+	var v int64
+	err = d.conn.QueryRowContext(context.Background(), query).Scan(&v, &dirty)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return lightmigrate.NoMigrationVersion, false, nil
+	case err != nil:
+		return 0, false, lightmigrate.DriverError{OrigErr: err, Query: []byte(query)}
+	}
+
+	return uint64(v), dirty, nil
+}
+
+// SetVersion is part of lightmigrate.MigrationDriver interface implementation.
+func (d *Driver) SetVersion(version uint64, dirty bool) error {
+	ctx := context.Background()
+	tx, err := d.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("unable to start transaction: %w", err)
+	}
+
+	table := d.dialect.QuoteIdentifier(d.table)
+	query := "DELETE FROM " + table
+	if _, err = tx.ExecContext(ctx, query); err != nil {
+		_ = tx.Rollback()
+		return lightmigrate.DriverError{OrigErr: err, Query: []byte(query)}
+	}
+
+	// Also re-write the version if it is dirty, so that failed migrations to NoMigrationVersion are recorded.
+	if version != lightmigrate.NoMigrationVersion || dirty {
+		query = "INSERT INTO " + table + " (version, dirty) VALUES (" +
+			d.dialect.Placeholder(1) + ", " + d.dialect.Placeholder(2) + ")"
+		if _, err = tx.ExecContext(ctx, query, int64(version), dirty); err != nil {
+			_ = tx.Rollback()
+			return lightmigrate.DriverError{OrigErr: err, Query: []byte(query)}
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("unable to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// RunMigration is part of lightmigrate.MigrationDriver interface implementation.
+func (d *Driver) RunMigration(migration io.Reader) error {
+	return d.RunMigrationContext(context.Background(), migration)
+}
+
+// RunMigrationContext is part of lightmigrate.MigrationDriverContext interface implementation.
+func (d *Driver) RunMigrationContext(ctx context.Context, migration io.Reader) error {
+	body, err := io.ReadAll(migration)
+	if err != nil {
+		return fmt.Errorf("unable to read migration: %w", err)
+	}
+
+	statements := []statement{{Line: 1, Query: string(body)}}
+	if d.splitStatements {
+		statements = splitStatements(string(body))
+	}
+
+	if !d.transactions || !d.transactional(string(body)) {
+		return d.execStatements(ctx, d.conn, statements)
+	}
+
+	tx, err := d.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("unable to start transaction: %w", err)
+	}
+	if err = d.execStatements(ctx, tx, statements); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("unable to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// transactional reports whether the statements of the migration can be executed within a transaction.
+func (d *Driver) transactional(body string) bool {
+	td, ok := d.dialect.(TransactionDialect)
+	if !ok {
+		return true
+	}
+	statements := splitStatements(body)
+	queries := make([]string, len(statements))
+	for i, s := range statements {
+		queries[i] = s.Query
+	}
+	return td.Transactional(queries)
+}
+
+// execer is implemented by sql.Conn and sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// execStatements executes all statements in the given order.
+func (d *Driver) execStatements(ctx context.Context, e execer, statements []statement) error {
+	for _, s := range statements {
+		if d.verbose {
+			d.logger.Printf("executing statement in line %d", s.Line)
+		}
+		if _, err := e.ExecContext(ctx, s.Query); err != nil {
+			return lightmigrate.DriverError{Line: s.Line, Query: []byte(s.Query), OrigErr: err}
+		}
+	}
+	return nil
+}
+
+// RecordApplied is part of lightmigrate.HistoryDriver interface implementation.
+// Records are numbered in the order they are recorded, the migrator holds the lock while recording.
+func (d *Driver) RecordApplied(migration lightmigrate.AppliedMigration) error {
+	ctx := context.Background()
+	tx, err := d.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("unable to start transaction: %w", err)
+	}
+
+	table := d.dialect.QuoteIdentifier(d.historyTable)
+	query := "SELECT COUNT(*) FROM " + table
+	var count int64
+	if err = tx.QueryRowContext(ctx, query).Scan(&count); err != nil {
+		_ = tx.Rollback()
+		return lightmigrate.DriverError{OrigErr: err, Query: []byte(query)}
+	}
+
+	placeholders := make([]string, 8)
+	for i := range placeholders {
+		placeholders[i] = d.dialect.Placeholder(i + 1)
+	}
+	query = "INSERT INTO " + table + " (id, version, identifier, direction, checksum, started_at, finished_at, applied_by)" +
+		" VALUES (" + strings.Join(placeholders, ", ") + ")"
+	_, err = tx.ExecContext(ctx, query, count+1, int64(migration.Version), migration.Identifier,
+		string(migration.Direction), migration.Checksum, formatTime(migration.StartedAt),
+		formatTime(migration.FinishedAt), migration.AppliedBy)
+	if err != nil {
+		_ = tx.Rollback()
+		return lightmigrate.DriverError{OrigErr: err, Query: []byte(query)}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("unable to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ListApplied is part of lightmigrate.HistoryDriver interface implementation.
+func (d *Driver) ListApplied() ([]lightmigrate.AppliedMigration, error) {
+	query := "SELECT id, version, identifier, direction, checksum, started_at, finished_at, applied_by FROM " +
+		d.dialect.QuoteIdentifier(d.historyTable) + " ORDER BY id"
+	rows, err := d.conn.QueryContext(context.Background(), query)
+	if err != nil {
+		return nil, lightmigrate.DriverError{OrigErr: err, Query: []byte(query)}
+	}
+	defer rows.Close()
+
+	history := make([]lightmigrate.AppliedMigration, 0)
+	for rows.Next() {
+		var id, version int64
+		var direction, startedAt, finishedAt string
+		var h lightmigrate.AppliedMigration
+		err = rows.Scan(&id, &version, &h.Identifier, &direction, &h.Checksum, &startedAt, &finishedAt, &h.AppliedBy)
+		if err != nil {
+			return nil, lightmigrate.DriverError{OrigErr: err, Query: []byte(query)}
+		}
+		h.Version = uint64(version)
+		h.Direction = lightmigrate.Direction(direction)
+		if h.StartedAt, err = time.Parse(time.RFC3339Nano, startedAt); err != nil {
+			return nil, fmt.Errorf("invalid start time of migration %d: %w", version, err)
+		}
+		if h.FinishedAt, err = time.Parse(time.RFC3339Nano, finishedAt); err != nil {
+			return nil, fmt.Errorf("invalid finish time of migration %d: %w", version, err)
+		}
+		history = append(history, h)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, lightmigrate.DriverError{OrigErr: err, Query: []byte(query)}
+	}
+
+	return history, nil
+}
+
+// formatTime returns the time as RFC 3339 string in UTC.
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// Reset is part of lightmigrate.MigrationDriver interface implementation.
+// The version and history tables are dropped.
+func (d *Driver) Reset() error {
+	for _, table := range []string{d.table, d.historyTable} {
+		query := "DROP TABLE IF EXISTS " + d.dialect.QuoteIdentifier(table)
+		if _, err := d.conn.ExecContext(context.Background(), query); err != nil {
+			return lightmigrate.DriverError{OrigErr: err, Query: []byte(query)}
+		}
+	}
+	return nil
+}
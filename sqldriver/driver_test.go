@@ -0,0 +1,374 @@
+package sqldriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/h44z/lightmigrate"
+	"github.com/h44z/lightmigrate/test"
+)
+
+func getTestDriver(t *testing.T, opts ...DriverOption) (*Driver, *test.MockSQL) {
+	db, mock := test.NewMockSQL()
+	d, err := NewDriver(db, opts...)
+	if err != nil {
+		t.Fatalf("unable to setup driver: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = d.Close()
+		_ = db.Close()
+	})
+	return d, mock
+}
+
+func TestNewDriver(t *testing.T) {
+	_, mock := getTestDriver(t, WithVersionTable("app.migrations"), WithDialect(PostgresDialect{}))
+
+	want := []string{
+		`CREATE TABLE IF NOT EXISTS "app"."migrations" (version BIGINT NOT NULL, dirty BOOLEAN NOT NULL)`,
+		`CREATE TABLE IF NOT EXISTS "app"."migrations_history" (id BIGINT NOT NULL, version BIGINT NOT NULL, ` +
+			`identifier VARCHAR(255) NOT NULL, direction VARCHAR(4) NOT NULL, checksum VARCHAR(64) NOT NULL, ` +
+			`started_at VARCHAR(35) NOT NULL, finished_at VARCHAR(35) NOT NULL, applied_by VARCHAR(255) NOT NULL, PRIMARY KEY (id))`,
+	}
+	if got := mock.GetStatements(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected statements: %v", got)
+	}
+}
+
+func TestNewDriver_Error(t *testing.T) {
+	db, mock := test.NewMockSQL()
+	mock.FailOn = "CREATE TABLE"
+	mock.Error = errors.New("permission denied")
+
+	_, err := NewDriver(db)
+	var driverErr lightmigrate.DriverError
+	if !errors.As(err, &driverErr) || !errors.Is(err, mock.Error) {
+		t.Fatalf("expected driver error, got: %v", err)
+	}
+}
+
+func TestDriver_Version(t *testing.T) {
+	d, mock := getTestDriver(t, WithDialect(PostgresDialect{}))
+
+	version, dirty, err := d.GetVersion()
+	if err != nil || version != lightmigrate.NoMigrationVersion || dirty {
+		t.Fatalf("unexpected initial version: %d, %t, %v", version, dirty, err)
+	}
+
+	if err = d.SetVersion(3, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	version, dirty, err = d.GetVersion()
+	if err != nil || version != 3 || !dirty {
+		t.Fatalf("unexpected version: %d, %t, %v", version, dirty, err)
+	}
+
+	if err = d.SetVersion(lightmigrate.NoMigrationVersion, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	version, dirty, err = d.GetVersion()
+	if err != nil || version != lightmigrate.NoMigrationVersion || dirty {
+		t.Fatalf("unexpected version: %d, %t, %v", version, dirty, err)
+	}
+
+	statements := mock.GetStatements()
+	if !reflect.DeepEqual(statements[3:7], []string{
+		"BEGIN",
+		`DELETE FROM "schema_migrations"`,
+		`INSERT INTO "schema_migrations" (version, dirty) VALUES ($1, $2)`,
+		"COMMIT",
+	}) {
+		t.Fatalf("unexpected statements: %v", statements)
+	}
+}
+
+func TestDriver_SetVersion_Error(t *testing.T) {
+	d, mock := getTestDriver(t)
+	mock.FailOn = "INSERT INTO"
+	mock.Error = errors.New("insert failed")
+
+	if err := d.SetVersion(1, false); !errors.Is(err, mock.Error) {
+		t.Fatalf("expected insert error, got: %v", err)
+	}
+	if got := mock.GetStatements(); got[len(got)-1] != "ROLLBACK" {
+		t.Fatalf("expected rollback, got: %v", got)
+	}
+}
+
+func TestDriver_RunMigration(t *testing.T) {
+	d, mock := getTestDriver(t)
+
+	err := d.RunMigration(strings.NewReader("CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"CREATE TABLE a (id INT)", "CREATE TABLE b (id INT)"}
+	if got := mock.GetStatements()[2:]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected statements: %v", got)
+	}
+}
+
+func TestDriver_RunMigration_NoSplitting(t *testing.T) {
+	d, mock := getTestDriver(t, WithStatementSplitting(false))
+
+	body := "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);"
+	if err := d.RunMigration(strings.NewReader(body)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := mock.GetStatements()[2:]; !reflect.DeepEqual(got, []string{body}) {
+		t.Fatalf("unexpected statements: %v", got)
+	}
+}
+
+func TestDriver_RunMigration_Transaction(t *testing.T) {
+	d, mock := getTestDriver(t, WithTransactions(true))
+
+	if err := d.RunMigration(strings.NewReader("SELECT 1; SELECT 2;")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"BEGIN", "SELECT 1", "SELECT 2", "COMMIT"}
+	if got := mock.GetStatements()[2:]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected statements: %v", got)
+	}
+}
+
+func TestDriver_RunMigration_Error(t *testing.T) {
+	d, mock := getTestDriver(t, WithTransactions(true))
+	mock.FailOn = "INVALID"
+	mock.Error = errors.New("syntax error")
+
+	err := d.RunMigration(strings.NewReader("SELECT 1;\n\nINVALID;\nSELECT 2;"))
+	var driverErr lightmigrate.DriverError
+	if !errors.As(err, &driverErr) {
+		t.Fatalf("expected driver error, got: %v", err)
+	}
+	if driverErr.Line != 3 || string(driverErr.Query) != "INVALID" || driverErr.OrigErr != mock.Error {
+		t.Fatalf("unexpected driver error: %#v", driverErr)
+	}
+
+	want := []string{"BEGIN", "SELECT 1", "INVALID", "ROLLBACK"}
+	if got := mock.GetStatements()[2:]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected statements: %v", got)
+	}
+}
+
+func TestDriver_RunMigrationContext_Canceled(t *testing.T) {
+	d, _ := getTestDriver(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := d.RunMigrationContext(ctx, strings.NewReader("SELECT 1;"))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled error, got: %v", err)
+	}
+}
+
+func TestDriver_Lock(t *testing.T) {
+	d, mock := getTestDriver(t, WithDialect(PostgresDialect{}))
+
+	if err := d.Lock(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := d.Lock(); !errors.Is(err, lightmigrate.ErrLocked) {
+		t.Fatalf("expected ErrLocked, got: %v", err)
+	}
+	if err := d.Unlock(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := d.Unlock(); err != nil {
+		t.Fatalf("unexpected error on second unlock: %v", err)
+	}
+
+	want := []string{"SELECT pg_advisory_lock($1)", "SELECT pg_advisory_unlock($1)"}
+	if got := mock.GetStatements()[2:]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected statements: %v", got)
+	}
+	if mock.Args[2][0] != LockKey(DefaultVersionTable) {
+		t.Fatalf("unexpected lock key: %v", mock.Args[2])
+	}
+}
+
+func TestDriver_Unlock_NotHeld(t *testing.T) {
+	d, mock := getTestDriver(t, WithDialect(PostgresDialect{}))
+	mock.QueryResults["SELECT pg_advisory_unlock"] = [][]driver.Value{{false}}
+
+	if err := d.Lock(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := d.Unlock(); err == nil || !strings.Contains(err.Error(), "was not held") {
+		t.Fatalf("expected not held error, got: %v", err)
+	}
+}
+
+func TestDriver_Lock_MySQL(t *testing.T) {
+	d, mock := getTestDriver(t, WithDialect(MySQLDialect{}))
+	mock.QueryResults["SELECT GET_LOCK"] = [][]driver.Value{{int64(0)}}
+
+	if err := d.Lock(); !errors.Is(err, lightmigrate.ErrLocked) {
+		t.Fatalf("expected ErrLocked, got: %v", err)
+	}
+
+	mock.QueryResults["SELECT GET_LOCK"] = [][]driver.Value{{int64(1)}}
+	if err := d.Lock(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := d.Unlock(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestDriver_Lock_Error(t *testing.T) {
+	d, mock := getTestDriver(t, WithDialect(PostgresDialect{}))
+	mock.FailOn = "pg_advisory_lock"
+	mock.Error = errors.New("connection lost")
+
+	if err := d.Lock(); !errors.Is(err, mock.Error) {
+		t.Fatalf("expected lock error, got: %v", err)
+	}
+	if err := d.Lock(); !errors.Is(err, mock.Error) {
+		t.Fatalf("expected lock to be retried, got: %v", err)
+	}
+}
+
+func TestDriver_Reset(t *testing.T) {
+	d, mock := getTestDriver(t, WithDialect(MySQLDialect{}))
+
+	if err := d.Reset(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"DROP TABLE IF EXISTS `schema_migrations`", "DROP TABLE IF EXISTS `schema_migrations_history`"}
+	if got := mock.GetStatements()[2:]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected statements: %v", got)
+	}
+}
+
+func TestDriver_Migrator(t *testing.T) {
+	d, mock := getTestDriver(t, WithDialect(SQLiteDialect{}))
+
+	source, err := lightmigrate.NewFsSource(os.DirFS("../test"), "sample-migrations")
+	if err != nil {
+		t.Fatalf("unable to setup source: %v", err)
+	}
+	migrator, err := lightmigrate.NewMigrator(source, d)
+	if err != nil {
+		t.Fatalf("unable to setup migrator: %v", err)
+	}
+
+	if err = migrator.Migrate(2); err != nil {
+		t.Fatalf("unexpected migration error: %v", err)
+	}
+
+	version, dirty, err := d.GetVersion()
+	if err != nil || version != 2 || dirty {
+		t.Fatalf("unexpected version: %d, %t, %v", version, dirty, err)
+	}
+	if len(mock.GetStatements()) < 10 {
+		t.Fatalf("expected migrations to be executed, got: %v", mock.GetStatements())
+	}
+
+	history, err := d.ListApplied()
+	if err != nil || len(history) != 2 || history[1].Version != 2 || history[1].Checksum == "" {
+		t.Fatalf("expected history of applied migrations, got: %v (%v)", history, err)
+	}
+}
+
+func TestDriver_History(t *testing.T) {
+	d, mock := getTestDriver(t, WithDialect(PostgresDialect{}), WithHistoryTable("audit.migrations"))
+
+	var _ lightmigrate.HistoryDriver = d
+	history, err := d.ListApplied()
+	if err != nil || len(history) != 0 {
+		t.Fatalf("expected empty history, got: %v (%v)", history, err)
+	}
+
+	startedAt := time.Date(2022, 4, 12, 21, 41, 16, 123456789, time.UTC)
+	want := []lightmigrate.AppliedMigration{
+		{Version: 1, Identifier: "init", Direction: lightmigrate.Up, Checksum: "abc", StartedAt: startedAt,
+			FinishedAt: startedAt.Add(time.Second), AppliedBy: "host"},
+		{Version: 1, Identifier: "init", Direction: lightmigrate.Down, StartedAt: startedAt.Add(time.Minute),
+			FinishedAt: startedAt.Add(time.Minute), AppliedBy: "host"},
+	}
+	for _, h := range want {
+		if err = d.RecordApplied(h); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	history, err = d.ListApplied()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(history, want) {
+		t.Fatalf("unexpected history: %v", history)
+	}
+
+	rows := mock.Tables[`"audit"."migrations"`]
+	if len(rows) != 2 || rows[0][0] != int64(1) || rows[1][0] != int64(2) {
+		t.Fatalf("expected sequential ids, got: %v", rows)
+	}
+	if rows[0][5] != "2022-04-12T21:41:16.123456789Z" {
+		t.Fatalf("unexpected start time: %v", rows[0][5])
+	}
+}
+
+func TestDriver_Migrator_OutOfOrder(t *testing.T) {
+	d, _ := getTestDriver(t, WithDialect(SQLiteDialect{}))
+	files := map[string]string{
+		"1_a.up.sql": "CREATE TABLE a (id INT)",
+		"3_c.up.sql": "CREATE TABLE c (id INT)",
+	}
+	source, _ := lightmigrate.NewMemorySource(files)
+	migrator, _ := lightmigrate.NewMigrator(source, d)
+	if err := migrator.Migrate(3); err != nil {
+		t.Fatalf("unexpected migration error: %v", err)
+	}
+
+	// a late merged migration
+	files["2_b.up.sql"] = "CREATE TABLE b (id INT)"
+	source, _ = lightmigrate.NewMemorySource(files)
+	migrator, _ = lightmigrate.NewMigrator(source, d)
+
+	var skipped lightmigrate.ErrSkippedMigrations
+	if err := migrator.Migrate(3); !errors.As(err, &skipped) || !reflect.DeepEqual(skipped.Versions, []uint64{2}) {
+		t.Fatalf("expected skipped migration 2, got: %v", err)
+	}
+}
+
+func TestDriver_History_Error(t *testing.T) {
+	d, mock := getTestDriver(t)
+	mock.FailOn = "schema_migrations_history"
+	mock.Error = errors.New("connection lost")
+
+	if err := d.RecordApplied(lightmigrate.AppliedMigration{Version: 1}); !errors.Is(err, mock.Error) {
+		t.Fatalf("expected record error, got: %v", err)
+	}
+	if _, err := d.ListApplied(); !errors.Is(err, mock.Error) {
+		t.Fatalf("expected list error, got: %v", err)
+	}
+
+	mock.FailOn = ""
+	mock.Tables[`"schema_migrations_history"`] = [][]driver.Value{{int64(1), int64(1), "init", "up", "", "invalid", "invalid", "host"}}
+	if _, err := d.ListApplied(); err == nil {
+		t.Fatalf("expected invalid time error")
+	}
+}
+
+func Test_quoteIdentifier(t *testing.T) {
+	if got := (GenericDialect{}).QuoteIdentifier(`a"b.c`); got != `"a""b"."c"` {
+		t.Fatalf("unexpected identifier: %s", got)
+	}
+	if got := (MySQLDialect{}).QuoteIdentifier("db.table"); got != "`db`.`table`" {
+		t.Fatalf("unexpected identifier: %s", got)
+	}
+}
@@ -0,0 +1,99 @@
+package sqldriver
+
+import (
+	"regexp"
+	"strings"
+)
+
+// dollarQuoteRegex matches the start of a PostgreSQL dollar quoted string, e.g. $$ or $body$.
+var dollarQuoteRegex = regexp.MustCompile(`^\$[A-Za-z_]*\$`)
+
+// statement is a single statement of a migration.
+type statement struct {
+	// Line is the line number of the statement start within the migration.
+	Line uint
+
+	// Query is the statement without the trailing semicolon.
+	Query string
+}
+
+// splitStatements splits a migration into statements separated by semicolons. Semicolons within
+// quotes, comments and dollar quoted strings are ignored. Statements that only contain whitespace
+// or comments are dropped. Backslash escapes within quotes are not supported, use doubled quotes instead.
+func splitStatements(migration string) []statement {
+	var statements []statement
+
+	start := 0 // index of the first code character of the current statement
+	line := uint(1)
+	startLine := uint(0) // line of the first code character of the current statement, 0 if none yet
+	appendStatement := func(end int) {
+		if startLine != 0 {
+			statements = append(statements, statement{
+				Line:  startLine,
+				Query: strings.TrimSpace(migration[start:end]),
+			})
+		}
+		startLine = 0
+	}
+
+	for i := 0; i < len(migration); i++ {
+		c := migration[i]
+		switch {
+		case c == '\n':
+			line++
+			continue
+		case c == ' ' || c == '\t' || c == '\r':
+			continue
+		case c == ';':
+			appendStatement(i)
+			continue
+		case strings.HasPrefix(migration[i:], "--"):
+			// continue at the newline, so that it gets counted
+			idx := strings.IndexByte(migration[i:], '\n')
+			if idx < 0 {
+				i = len(migration)
+			} else {
+				i += idx - 1
+			}
+			continue
+		case strings.HasPrefix(migration[i:], "/*"):
+			end := skipUntil(migration, i+2, "*/")
+			line += uint(strings.Count(migration[i:end], "\n"))
+			i = end - 1
+			continue
+		}
+
+		if startLine == 0 {
+			start = i // leading whitespace and comments are dropped
+			startLine = line
+		}
+
+		end := i + 1
+		switch c {
+		case '\'', '"', '`':
+			end = skipUntil(migration, i+1, string(c))
+		case '$':
+			if tag := dollarQuoteRegex.FindString(migration[i:]); tag != "" {
+				end = skipUntil(migration, i+len(tag), tag)
+			}
+		}
+		line += uint(strings.Count(migration[i:end], "\n"))
+		i = end - 1
+	}
+	appendStatement(len(migration))
+
+	return statements
+}
+
+// skipUntil returns the index after the next occurrence of the terminator, starting at offset.
+// If the terminator does not exist, the length of s is returned.
+func skipUntil(s string, offset int, terminator string) int {
+	if offset > len(s) {
+		return len(s)
+	}
+	idx := strings.Index(s[offset:], terminator)
+	if idx < 0 {
+		return len(s)
+	}
+	return offset + idx + len(terminator)
+}
@@ -0,0 +1,69 @@
+package sqldriver
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_splitStatements(t *testing.T) {
+	tests := []struct {
+		name      string
+		migration string
+		want      []statement
+	}{
+		{
+			name:      "empty",
+			migration: " \n ",
+			want:      nil,
+		},
+		{
+			name:      "single statement without semicolon",
+			migration: "CREATE TABLE a (id INT)",
+			want:      []statement{{Line: 1, Query: "CREATE TABLE a (id INT)"}},
+		},
+		{
+			name:      "multiple statements",
+			migration: "CREATE TABLE a (id INT);\n\nCREATE TABLE b (id INT);\n",
+			want: []statement{
+				{Line: 1, Query: "CREATE TABLE a (id INT)"},
+				{Line: 3, Query: "CREATE TABLE b (id INT)"},
+			},
+		},
+		{
+			name:      "semicolons in quotes",
+			migration: "INSERT INTO a VALUES ('x;y', \"a;b\", `c;d`, 'it''s;');\nSELECT 1;",
+			want: []statement{
+				{Line: 1, Query: "INSERT INTO a VALUES ('x;y', \"a;b\", `c;d`, 'it''s;')"},
+				{Line: 2, Query: "SELECT 1"},
+			},
+		},
+		{
+			name:      "comments",
+			migration: "-- first; statement\nSELECT 1; /* multi\nline; */ SELECT 2;\n-- trailing comment;",
+			want: []statement{
+				{Line: 2, Query: "SELECT 1"},
+				{Line: 3, Query: "SELECT 2"},
+			},
+		},
+		{
+			name:      "dollar quotes",
+			migration: "CREATE FUNCTION f() RETURNS INT AS $body$\nBEGIN\n  RETURN 1;\nEND;\n$body$ LANGUAGE plpgsql;\nSELECT $$a;b$$, $1;",
+			want: []statement{
+				{Line: 1, Query: "CREATE FUNCTION f() RETURNS INT AS $body$\nBEGIN\n  RETURN 1;\nEND;\n$body$ LANGUAGE plpgsql"},
+				{Line: 6, Query: "SELECT $$a;b$$, $1"},
+			},
+		},
+		{
+			name:      "unterminated quote",
+			migration: "SELECT 'abc;",
+			want:      []statement{{Line: 1, Query: "SELECT 'abc;"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitStatements(tt.migration); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitStatements() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
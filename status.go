@@ -0,0 +1,131 @@
+package lightmigrate
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// MigrationStatus describes the state of a single migration version known to the migration source.
+type MigrationStatus struct {
+	// Version is the version of the migration.
+	Version uint64
+
+	// Identifier helps finding the migration in the source.
+	Identifier string
+
+	// Applied is true if the migration has been applied to the database. If the driver implements
+	// HistoryDriver, the recorded history is used for all versions from the lowest recorded version on. Otherwise,
+	// or if the history is empty, all versions up to the current version are applied.
+	Applied bool
+
+	// MissingUp is true if the source contains no up migration for this version.
+	MissingUp bool
+
+	// MissingDown is true if the source contains no down migration for this version.
+	MissingDown bool
+}
+
+// StatusReport compares the migrations of the migration source with the database state.
+type StatusReport struct {
+	// CurrentVersion is the currently active database version.
+	CurrentVersion uint64
+
+	// Dirty is true if a previous migration failed.
+	Dirty bool
+
+	// Migrations contains the status of all migrations in the source, ordered by version.
+	Migrations []MigrationStatus
+}
+
+// Pending returns all migrations that have not been applied yet.
+func (r *StatusReport) Pending() []MigrationStatus {
+	pending := make([]MigrationStatus, 0)
+	for _, s := range r.Migrations {
+		if !s.Applied {
+			pending = append(pending, s)
+		}
+	}
+	return pending
+}
+
+func (m *migrator) Status() (*StatusReport, error) {
+	// avoid multiple concurrent runs of the migration
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	curVersion, dirty, err := m.driver.GetVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &StatusReport{
+		CurrentVersion: curVersion,
+		Dirty:          dirty,
+		Migrations:     make([]MigrationStatus, 0),
+	}
+
+	applied, baseline, hasHistory, err := m.getAppliedVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := m.source.First()
+	for err == nil {
+		status, statusErr := m.getMigrationStatus(version, curVersion)
+		if statusErr != nil {
+			return nil, statusErr
+		}
+		if hasHistory && version >= baseline {
+			_, status.Applied = applied[version]
+		}
+		report.Migrations = append(report.Migrations, status)
+
+		version, err = m.source.Next(version)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// getMigrationStatus checks which migration files exist in the source for the given version.
+func (m *migrator) getMigrationStatus(version, currentVersion uint64) (MigrationStatus, error) {
+	status := MigrationStatus{
+		Version: version,
+		Applied: version <= currentVersion,
+	}
+
+	for _, direction := range []Direction{Up, Down} {
+		var err error
+		var contents io.ReadCloser
+		var identifier string
+
+		switch direction {
+		case Up:
+			contents, identifier, err = m.source.ReadUp(version)
+		case Down:
+			contents, identifier, err = m.source.ReadDown(version)
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return status, err
+		}
+
+		if err != nil {
+			if direction == Up {
+				status.MissingUp = true
+			} else {
+				status.MissingDown = true
+			}
+			continue
+		}
+
+		_ = contents.Close()
+		if status.Identifier == "" {
+			status.Identifier = identifier
+		}
+	}
+
+	return status, nil
+}
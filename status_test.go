@@ -0,0 +1,93 @@
+package lightmigrate
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/h44z/lightmigrate/test"
+)
+
+func Test_migrator_Status(t *testing.T) {
+	m := getTestMigrator()
+	s, _ := test.NewMockSource(1, 3)
+	s.Identifier = "mock"
+	m.source = s
+	m.driver.(*test.MockDriver).Version = 2
+	m.driver.(*test.MockDriver).Dirty = true
+
+	got, err := m.Status()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := &StatusReport{
+		CurrentVersion: 2,
+		Dirty:          true,
+		Migrations: []MigrationStatus{
+			{Version: 1, Identifier: "mock", Applied: true},
+			{Version: 2, Identifier: "mock", Applied: true},
+			{Version: 3, Identifier: "mock", Applied: false},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected report: %v, got: %v", want, got)
+	}
+
+	pending := got.Pending()
+	if len(pending) != 1 || pending[0].Version != 3 {
+		t.Fatalf("expected version 3 to be pending, got: %v", pending)
+	}
+}
+
+func Test_migrator_Status_MissingFiles(t *testing.T) {
+	m := getTestMigrator()
+	m.source = getTestSource(t, "incomplete-migrations")
+	m.driver.(*test.MockDriver).Version = 1
+
+	got, err := m.Status()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []MigrationStatus{
+		{Version: 1, Identifier: "first", Applied: true},
+		{Version: 2, Identifier: "no-down", Applied: false, MissingDown: true},
+		{Version: 3, Identifier: "no-up", Applied: false, MissingUp: true},
+	}
+	if !reflect.DeepEqual(got.Migrations, want) {
+		t.Fatalf("expected migrations: %v, got: %v", want, got.Migrations)
+	}
+}
+
+func Test_migrator_Status_NoMigrations(t *testing.T) {
+	m := getTestMigrator()
+	m.source = getTestSource(t, "no-migrations")
+
+	got, err := m.Status()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got.Migrations) != 0 {
+		t.Fatalf("expected no migrations, got: %v", got.Migrations)
+	}
+}
+
+func Test_migrator_Status_DriverError(t *testing.T) {
+	m := getTestMigrator()
+	m.driver.(*test.MockDriver).Error = errors.New("drivererror")
+
+	_, err := m.Status()
+	if err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+}
+
+func Test_migrator_Status_SourceError(t *testing.T) {
+	m := getTestMigrator()
+	m.source.(*test.MockSource).Error = errors.New("sourceerror")
+
+	_, err := m.Status()
+	if err == nil || errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected source error, got: %v", err)
+	}
+}
@@ -0,0 +1,4 @@
+-- +goose Up
+CREATE TABLE post (id INT);
+-- +goose Down
+DROP TABLE post;
@@ -0,0 +1 @@
+CREATE TABLE audit;
@@ -1,10 +1,12 @@
 package test
 
 import (
+	"context"
 	"io"
 )
 
-// MockDriver is a mocked driver implementation used for testing.
+// MockDriver is a mocked driver implementation used for testing. Use the memdriver package
+// to record the applied migrations.
 type MockDriver struct {
 	Error   error
 	Version uint64
@@ -55,3 +57,34 @@ func (m *MockDriver) RunMigration(migration io.Reader) error {
 func (m *MockDriver) Reset() error {
 	return m.Error
 }
+
+// MockContextDriver is a mocked driver implementation that supports contexts, used for testing.
+type MockContextDriver struct {
+	*MockDriver
+
+	// Contexts contains all contexts that were passed to the driver.
+	Contexts []context.Context
+}
+
+// NewMockContextDriver instantiates a new mocked context aware driver.
+func NewMockContextDriver() (*MockContextDriver, error) {
+	return &MockContextDriver{MockDriver: &MockDriver{}}, nil
+}
+
+// LockContext is part of lightmigrate.MigrationDriverContext interface implementation.
+func (m *MockContextDriver) LockContext(ctx context.Context) error {
+	m.Contexts = append(m.Contexts, ctx)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return m.Lock()
+}
+
+// RunMigrationContext is part of lightmigrate.MigrationDriverContext interface implementation.
+func (m *MockContextDriver) RunMigrationContext(ctx context.Context, migration io.Reader) error {
+	m.Contexts = append(m.Contexts, ctx)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return m.RunMigration(migration)
+}
@@ -0,0 +1,205 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+)
+
+// MockSQL is a mocked database/sql driver used for testing. It records all statements and
+// keeps the inserted rows of all tables in memory.
+type MockSQL struct {
+	lock sync.Mutex
+
+	// Statements contains all executed statements and queries, transactions are recorded as BEGIN, COMMIT and ROLLBACK.
+	Statements []string
+	// Args contains the arguments of all executed statements.
+	Args [][]driver.Value
+	// Tables contains the rows of all tables, keyed by the table name as used in the statements.
+	// Queries return all rows in insertion order, SELECT COUNT(*) returns the number of rows.
+	Tables map[string][][]driver.Value
+	// QueryResults contains the result rows for queries starting with the given prefix.
+	QueryResults map[string][][]driver.Value
+	// FailOn makes all statements containing this string fail with Error.
+	FailOn string
+	// Error is returned for all statements matching FailOn.
+	Error error
+}
+
+// NewMockSQL instantiates a new mocked database/sql database.
+func NewMockSQL() (*sql.DB, *MockSQL) {
+	m := &MockSQL{
+		Tables:       make(map[string][][]driver.Value),
+		QueryResults: make(map[string][][]driver.Value),
+	}
+	return sql.OpenDB(m), m
+}
+
+// GetStatements returns a copy of all recorded statements.
+func (m *MockSQL) GetStatements() []string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return append([]string(nil), m.Statements...)
+}
+
+// Connect is part of driver.Connector interface implementation.
+func (m *MockSQL) Connect(_ context.Context) (driver.Conn, error) {
+	return &mockSQLConn{db: m}, nil
+}
+
+// Driver is part of driver.Connector interface implementation.
+func (m *MockSQL) Driver() driver.Driver {
+	return mockSQLDriver{db: m}
+}
+
+func (m *MockSQL) exec(query string, args []driver.Value) ([][]driver.Value, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.Statements = append(m.Statements, query)
+	m.Args = append(m.Args, args)
+
+	if m.FailOn != "" && strings.Contains(query, m.FailOn) {
+		return nil, m.Error
+	}
+
+	normalized := strings.ToUpper(strings.TrimSpace(query))
+	switch {
+	case strings.HasPrefix(normalized, "DELETE FROM"), strings.HasPrefix(normalized, "DROP TABLE"):
+		delete(m.Tables, tableName(query, normalized))
+	case strings.HasPrefix(normalized, "INSERT INTO"):
+		table := tableName(query, normalized)
+		m.Tables[table] = append(m.Tables[table], args)
+	case strings.HasPrefix(normalized, "SELECT COUNT(*) FROM"):
+		return [][]driver.Value{{int64(len(m.Tables[tableName(query, normalized)]))}}, nil
+	case strings.HasPrefix(normalized, "SELECT") && strings.Contains(normalized, " FROM "):
+		return m.Tables[tableName(query, normalized)], nil
+	}
+
+	for prefix, rows := range m.QueryResults {
+		if strings.HasPrefix(query, prefix) {
+			return rows, nil
+		}
+	}
+
+	return [][]driver.Value{{int64(1)}}, nil
+}
+
+// tableName returns the table name following INTO, FROM or TABLE [IF EXISTS].
+func tableName(query, normalized string) string {
+	for _, keyword := range []string{" INTO ", " FROM ", " TABLE IF EXISTS ", " TABLE "} {
+		if i := strings.Index(normalized, keyword); i >= 0 {
+			fields := strings.Fields(query[i+len(keyword):])
+			if len(fields) > 0 {
+				return fields[0]
+			}
+		}
+	}
+	return ""
+}
+
+type mockSQLDriver struct {
+	db *MockSQL
+}
+
+func (d mockSQLDriver) Open(_ string) (driver.Conn, error) {
+	return &mockSQLConn{db: d.db}, nil
+}
+
+type mockSQLConn struct {
+	db *MockSQL
+}
+
+func (c *mockSQLConn) Prepare(query string) (driver.Stmt, error) {
+	return &mockSQLStmt{db: c.db, query: query}, nil
+}
+
+func (c *mockSQLConn) Close() error {
+	return nil
+}
+
+func (c *mockSQLConn) Begin() (driver.Tx, error) {
+	if _, err := c.db.exec("BEGIN", nil); err != nil {
+		return nil, err
+	}
+	return &mockSQLTx{db: c.db}, nil
+}
+
+type mockSQLTx struct {
+	db *MockSQL
+}
+
+func (t *mockSQLTx) Commit() error {
+	_, err := t.db.exec("COMMIT", nil)
+	return err
+}
+
+func (t *mockSQLTx) Rollback() error {
+	_, err := t.db.exec("ROLLBACK", nil)
+	return err
+}
+
+type mockSQLStmt struct {
+	db    *MockSQL
+	query string
+}
+
+func (s *mockSQLStmt) Close() error {
+	return nil
+}
+
+func (s *mockSQLStmt) NumInput() int {
+	return -1
+}
+
+func (s *mockSQLStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if _, err := s.db.exec(s.query, args); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *mockSQLStmt) Query(args []driver.Value) (driver.Rows, error) {
+	rows, err := s.db.exec(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &mockSQLRows{rows: rows}, nil
+}
+
+type mockSQLRows struct {
+	rows [][]driver.Value
+	pos  int
+}
+
+func (r *mockSQLRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return []string{"version", "dirty"}
+	}
+	columns := make([]string, len(r.rows[0]))
+	for i := range columns {
+		columns[i] = "column"
+	}
+	return columns
+}
+
+func (r *mockSQLRows) Close() error {
+	return nil
+}
+
+func (r *mockSQLRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.rows) {
+		return io.EOF
+	}
+	if len(dest) != len(r.rows[r.pos]) {
+		return errors.New("invalid number of columns")
+	}
+	copy(dest, r.rows[r.pos])
+	r.pos++
+	return nil
+}
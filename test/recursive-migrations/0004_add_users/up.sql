@@ -0,0 +1 @@
+CREATE TABLE users;
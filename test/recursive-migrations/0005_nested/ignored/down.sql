@@ -0,0 +1 @@
+ignored
\ No newline at end of file
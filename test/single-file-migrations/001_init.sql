@@ -0,0 +1,6 @@
+-- initial schema
+-- +migrate Up
+CREATE TABLE users (id INT);
+
+-- +migrate Down
+DROP TABLE users;
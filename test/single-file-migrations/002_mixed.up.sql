@@ -0,0 +1 @@
+CREATE TABLE mixed (id INT);
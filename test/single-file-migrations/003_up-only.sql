@@ -0,0 +1,2 @@
+-- +migrate Up
+CREATE INDEX users_id ON users (id);